@@ -0,0 +1,247 @@
+package main
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// /api/v1 下的接口使用统一的响应格式：
+//
+//	成功 {"ok": true, "data": {...}}
+//	失败 {"ok": false, "error": {"code": "dir_not_found", "message": "目录不存在"}}
+//
+// 旧的 /api/... 接口保持原有格式，供旧版本的页面和其他电脑上的快传使用
+const apiV1Prefix = "/api/v1"
+
+// 请求是否来自 /api/v1
+func isAPIv1(r *http.Request) bool {
+	return r.URL.Path == apiV1Prefix || strings.HasPrefix(r.URL.Path, apiV1Prefix+"/")
+}
+
+// 返回成功结果，旧接口把 data 中的字段与 message、code 放在同一层并始终返回 200
+func writeResult(w http.ResponseWriter, r *http.Request, status int, message string, data map[string]any) {
+	if data == nil {
+		data = map[string]any{}
+	}
+	if isAPIv1(r) {
+		writeJSON(w, status, map[string]any{
+			"ok":   true,
+			"data": data,
+		})
+		return
+	}
+	legacy := maps.Clone(data)
+	legacy["message"] = message
+	legacy["code"] = http.StatusOK
+	writeJSON(w, http.StatusOK, legacy)
+}
+
+// 一个 /api/v1 接口，同时用于注册路由和生成 OpenAPI 文档
+type apiRoute struct {
+	Method  string
+	Path    string // 相对 /api/v1 的路径
+	Summary string
+	Handler http.HandlerFunc
+	Query   []apiParam
+	Body    string // 请求体的 schema 名称，multipart 表示上传文件，以 Form 结尾的为 multipart 表单
+	Result  string // 成功时 data 的 schema 名称
+	Content string // 成功时不是统一格式的响应类型，如文件下载
+	Status  int    // 成功时的状态码，默认 200
+	Errors  []int  // 可能返回的错误状态码
+}
+
+type apiParam struct {
+	Name     string
+	Type     string // string 或 integer
+	Required bool
+	Desc     string
+}
+
+var pathParam = apiParam{Name: "path", Type: "string", Desc: "相对共享文件夹的目录，为空表示根目录"}
+
+func (t *AppServer) apiRoutes() []apiRoute {
+	return []apiRoute{
+		{
+			Method: http.MethodGet, Path: "/info", Summary: "获取服务器地址",
+			Handler: t.infoHandler, Result: "Info",
+		},
+		{
+			Method: http.MethodGet, Path: "/files", Summary: "列出目录中的文件和文件夹",
+			Handler: t.getFileList, Query: []apiParam{pathParam}, Result: "FileList",
+			Errors: []int{http.StatusForbidden, http.StatusNotFound},
+		},
+		{
+			Method: http.MethodGet, Path: "/search", Summary: "按文件名搜索，每行返回一个 SearchResult，最后一行为 SearchSummary",
+			Handler: t.searchHandler, Content: "application/x-ndjson",
+			Query: []apiParam{
+				{Name: "q", Type: "string", Required: true, Desc: "关键字，支持拼音、首字母和通配符 *"},
+				pathParam,
+				{Name: "limit", Type: "integer", Desc: "最多返回的结果数"},
+			},
+			Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+		},
+		{
+			Method: http.MethodGet, Path: "/download", Summary: "下载文件，支持断点续传",
+			Handler: t.downloadHandler, Content: "application/octet-stream",
+			Query:  []apiParam{{Name: "path", Type: "string", Required: true, Desc: "相对共享文件夹的文件路径"}},
+			Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound},
+		},
+		{
+			Method: http.MethodGet, Path: "/limits", Summary: "获取上传限制和当前设备的剩余额度",
+			Handler: t.limitsHandler, Result: "Limits",
+		},
+		{
+			Method: http.MethodPost, Path: "/upload/request", Summary: "上传前请求对方确认，未开启确认时直接返回成功",
+			Handler: t.uploadRequestHandler, Body: "UploadRequest",
+			Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusRequestTimeout},
+		},
+		{
+			Method: http.MethodPost, Path: "/upload", Summary: "上传一个文件，表单字段为 file",
+			Handler: t.uploadHandler, Query: []apiParam{pathParam}, Body: "multipart",
+			Result: "UploadResult", Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusRequestTimeout,
+				http.StatusRequestEntityTooLarge, http.StatusInsufficientStorage, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodGet, Path: "/device", Summary: "获取当前设备",
+			Handler: t.deviceHandler, Result: "Device",
+		},
+		{
+			Method: http.MethodPost, Path: "/device", Summary: "修改当前设备的名称",
+			Handler: t.deviceHandler, Body: "DeviceRename", Result: "Device",
+			Errors: []int{http.StatusBadRequest},
+		},
+		{
+			Method: http.MethodGet, Path: "/links/{token}", Summary: "获取分享链接的信息，输入密码后文件夹链接同时返回 path 下的文件",
+			Handler: t.linkInfoHandler, Query: []apiParam{{Name: "path", Type: "string", Desc: "相对链接文件夹的目录"}}, Result: "LinkInfo",
+			Errors: []int{http.StatusNotFound, http.StatusGone},
+		},
+		{
+			Method: http.MethodPost, Path: "/links/{token}/unlock", Summary: "输入分享链接的密码，正确时保存到 cookie",
+			Handler: t.linkUnlockHandler, Body: "LinkUnlock",
+			Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusGone},
+		},
+		{
+			Method: http.MethodGet, Path: "/links/{token}/download", Summary: "通过分享链接下载文件，支持断点续传，开始下载时占用一次下载次数，同一设备续传时不重复计数",
+			Handler: t.linkDownloadHandler, Content: "application/octet-stream",
+			Query:  []apiParam{{Name: "path", Type: "string", Desc: "文件夹链接中相对链接文件夹的文件路径"}},
+			Errors: []int{http.StatusUnauthorized, http.StatusNotFound, http.StatusGone},
+		},
+		{
+			Method: http.MethodPost, Path: "/links/{token}/upload", Summary: "通过收集链接上传一个文件，name、note 字段需在 file 之前，同名文件自动改名",
+			Handler: t.linkUploadHandler, Body: "LinkUploadForm", Result: "UploadResult", Status: http.StatusCreated,
+			Errors: []int{http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusGone,
+				http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType, http.StatusInsufficientStorage, http.StatusInternalServerError},
+		},
+		{
+			Method: http.MethodGet, Path: "/events", Summary: "以 Server-Sent Events 推送服务器事件，如 shutdown 表示共享即将停止，data.seconds 为剩余秒数",
+			Handler: t.eventsHandler, Content: "text/event-stream",
+			Errors: []int{http.StatusServiceUnavailable},
+		},
+		{
+			Method: http.MethodGet, Path: "/openapi.json", Summary: "本接口的 OpenAPI 文档",
+			Handler: t.openAPIHandler, Content: "application/json",
+		},
+	}
+}
+
+// 注册 /api/v1 下的所有接口，同一路径的不同方法由 apiMethods 分发
+func (t *AppServer) registerAPIv1(mux *http.ServeMux) {
+	byPath := make(map[string]apiMethods)
+	for _, route := range t.apiRoutes() {
+		if byPath[route.Path] == nil {
+			byPath[route.Path] = make(apiMethods)
+		}
+		byPath[route.Path][route.Method] = route.Handler
+	}
+	for p, methods := range byPath {
+		mux.Handle(apiV1Prefix+p, t.CORS.Middleware(methods))
+	}
+	mux.Handle(apiV1Prefix+"/", t.CORS.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, r, http.StatusNotFound, "not_found")
+	})))
+}
+
+// 按请求方法分发，不支持的方法返回 405
+type apiMethods map[string]http.HandlerFunc
+
+func (m apiMethods) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h, ok := m[r.Method]; ok {
+		h(w, r)
+		return
+	}
+	allow := slices.Sorted(maps.Keys(m))
+	w.Header().Set("Allow", strings.Join(append(allow, http.MethodOptions), ", "))
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed")
+}
+
+// 服务器地址
+func (t *AppServer) infoHandler(w http.ResponseWriter, r *http.Request) {
+	writeResult(w, r, http.StatusOK, "ok", map[string]any{
+		"ip":   t.GetLocalIP(),
+		"port": t.port(),
+		"url":  t.BaseURL(),
+		"mode": t.shareMode(),
+	})
+}
+
+// 跨域访问配置，允许其他来源的网页调用 /api/v1
+type CORSConfig struct {
+	AllowedOrigins []string `json:"allowedOrigins"` // 允许的来源，如 http://localhost:3000，* 表示任意来源
+	MaxAge         int      `json:"maxAge"`         // 预检结果的缓存时间，单位秒
+}
+
+// 来源是否允许，返回 Access-Control-Allow-Origin 的值
+func (c CORSConfig) allowOrigin(origin string) (string, bool) {
+	for _, o := range c.AllowedOrigins {
+		if o == "*" {
+			return "*", true
+		}
+		if strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+			return origin, true
+		}
+	}
+	return "", false
+}
+
+// 为允许的来源添加跨域响应头并处理预检请求，未配置时不允许跨域
+func (c CORSConfig) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		allowed, ok := c.allowOrigin(origin)
+		if origin == "" || !ok {
+			if len(c.AllowedOrigins) > 0 {
+				w.Header().Add("Vary", "Origin")
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		h.Set("Access-Control-Allow-Origin", allowed)
+		// 指定来源时允许携带 cookie，以便识别设备
+		if allowed != "*" {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+		h.Set("Access-Control-Expose-Headers", "Content-Disposition, Content-Language")
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+			h.Set("Access-Control-Allow-Headers", "Content-Type, Accept-Language, Authorization")
+			if c.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(c.MaxAge))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
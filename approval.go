@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 等待桌面端确认的最长时间
+const approvalTimeout = 60 * time.Second
+
+// 等待确认的文件
+type UploadFile struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// 上传确认请求，交给桌面端弹窗询问
+type UploadRequest struct {
+	DeviceID string       // 发送设备ID
+	Device   string       // 发送设备名称
+	IP       string       // 发送设备IP
+	Agent    string       // 发送设备的浏览器标识
+	Files    []UploadFile // 准备上传的文件
+}
+
+// 根据请求生成确认请求
+func (t *AppServer) newUploadRequest(r *http.Request, files []UploadFile) UploadRequest {
+	id := deviceID(r)
+	dev, _ := t.Devices.Get(id)
+	return UploadRequest{
+		DeviceID: id,
+		Device:   dev.DisplayName(),
+		IP:       clientIP(r),
+		Agent:    r.UserAgent(),
+		Files:    files,
+	}
+}
+
+// 确认结果
+type approvalResult int
+
+const (
+	approvalAllowed approvalResult = iota
+	approvalDenied
+	approvalExpired
+)
+
+// 一次正在等待的确认，同一设备的并发上传共用一个结果
+type pendingApproval struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	done    chan struct{}
+	allowed bool
+}
+
+// 开启或关闭上传确认
+func (t *AppServer) SetRequireApproval(require bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.requireApproval = require
+}
+
+// 取消设备的上传许可，下次上传需要重新确认
+func (t *AppServer) ForgetApproval(deviceID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key := range t.approved {
+		if strings.HasPrefix(key, deviceID+"|") {
+			delete(t.approved, key)
+		}
+	}
+}
+
+// 上传许可同时绑定设备和IP，复制其他设备的 cookie 也需要重新确认
+func (req UploadRequest) approvalKey() string {
+	return req.DeviceID + "|" + req.IP
+}
+
+// 等待桌面端确认设备的上传请求，同意后本次共享期间不再询问
+func (t *AppServer) requestApproval(ctx context.Context, req UploadRequest) approvalResult {
+	key := req.approvalKey()
+	t.mu.Lock()
+	if !t.requireApproval || t.approved[key] || t.OnApprovalRequest == nil {
+		t.mu.Unlock()
+		return approvalAllowed
+	}
+	pending, waiting := t.pending[key]
+	var decide func(bool)
+	if !waiting {
+		pending = t.newPendingApproval(key)
+		decide = func(allowed bool) { t.decideApproval(key, pending, allowed) }
+	}
+	t.mu.Unlock()
+
+	if !waiting {
+		t.OnApprovalRequest(pending.ctx, req, decide)
+	}
+
+	select {
+	case <-pending.done:
+	case <-pending.ctx.Done():
+	case <-ctx.Done():
+		return approvalExpired
+	}
+	select {
+	case <-pending.done:
+		if pending.allowed {
+			return approvalAllowed
+		}
+		return approvalDenied
+	default:
+		return approvalExpired
+	}
+}
+
+// 创建一次等待中的确认，超时后自动移除，调用时需持有锁
+func (t *AppServer) newPendingApproval(device string) *pendingApproval {
+	ctx, cancel := context.WithTimeout(context.Background(), approvalTimeout)
+	pending := &pendingApproval{ctx: ctx, cancel: cancel, done: make(chan struct{})}
+	context.AfterFunc(ctx, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if t.pending[device] == pending {
+			delete(t.pending, device)
+		}
+	})
+
+	if t.pending == nil {
+		t.pending = make(map[string]*pendingApproval)
+	}
+	t.pending[device] = pending
+	return pending
+}
+
+// 记录桌面端的确认结果
+func (t *AppServer) decideApproval(device string, pending *pendingApproval, allowed bool) {
+	t.mu.Lock()
+	if t.pending[device] != pending {
+		t.mu.Unlock()
+		return // 已超时
+	}
+	delete(t.pending, device)
+	if allowed {
+		if t.approved == nil {
+			t.approved = make(map[string]bool)
+		}
+		t.approved[device] = true
+	}
+	pending.allowed = allowed
+	close(pending.done)
+	t.mu.Unlock()
+	pending.cancel()
+}
+
+// 根据确认结果返回错误，允许时返回 true
+func writeApprovalResult(w http.ResponseWriter, r *http.Request, result approvalResult) bool {
+	switch result {
+	case approvalDenied:
+		writeError(w, r, http.StatusForbidden, "upload_denied")
+		return false
+	case approvalExpired:
+		writeError(w, r, http.StatusRequestTimeout, "upload_approval_timeout")
+		return false
+	}
+	return true
+}
+
+// 上传前的确认请求处理函数，浏览器一次提交整批文件
+func (t *AppServer) uploadRequestHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed")
+		return
+	}
+	if !t.checkWrite(w, r) {
+		return
+	}
+
+	var body struct {
+		Files []UploadFile `json:"files"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, r, http.StatusBadRequest, "bad_request")
+		return
+	}
+
+	result := t.requestApproval(r.Context(), t.newUploadRequest(r, body.Files))
+	if !writeApprovalResult(w, r, result) {
+		return
+	}
+
+	writeResult(w, r, http.StatusOK, T(requestLang(r), "msg.upload_approved"), nil)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 网页及其使用的样式和图标，编译进程序中，局域网无法访问外网时页面也能正常显示。
+// 只有这里列出的文件可以被访问，不会读取本地文件系统。
+//
+//go:embed static/list.html static/upload.html static/share.html static/request.html static/assets
+var assetFS embed.FS
+
+// 缓存策略，静态资源缓存一天，页面每次都重新验证
+const (
+	assetMaxAge = "public, max-age=86400"
+	pageNoCache = "no-cache"
+)
+
+// 一个静态资源，内容不大时预先压缩好
+type staticAsset struct {
+	data        []byte
+	gzipped     []byte // 压缩后没有变小时为空
+	etag        string
+	contentType string
+}
+
+func newStaticAsset(name string, data []byte) *staticAsset {
+	sum := sha256.Sum256(data)
+	asset := &staticAsset{
+		data:        data,
+		etag:        hex.EncodeToString(sum[:8]),
+		contentType: mime.TypeByExtension(path.Ext(name)),
+	}
+	if asset.contentType == "" {
+		asset.contentType = http.DetectContentType(data)
+	}
+
+	var buf bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	zw.Write(data)
+	zw.Close()
+	if buf.Len() < len(data) {
+		asset.gzipped = buf.Bytes()
+	}
+	return asset
+}
+
+// 页面中的待翻译文本，如 {{t:web.upload}}
+var pageTextPattern = regexp.MustCompile(`\{\{t:([\w.]+)\}\}`)
+
+// 把页面中的待翻译文本替换为指定语言
+func localizePage(data []byte, lang Lang) []byte {
+	return pageTextPattern.ReplaceAllFunc(data, func(m []byte) []byte {
+		id := pageTextPattern.FindSubmatch(m)[1]
+		return []byte(T(lang, string(id)))
+	})
+}
+
+// 从内置文件中提供静态资源，路径与 static 目录中的相对路径一致
+type assetServer struct {
+	assets map[string]*staticAsset
+}
+
+func newAssetServer(fsys fs.FS) *assetServer {
+	s := &assetServer{assets: make(map[string]*staticAsset)}
+	fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil
+		}
+		// 页面为每种语言各生成一份，以 name@lang 为键
+		if path.Ext(name) == ".html" {
+			for _, lang := range supportedLangs {
+				s.assets[pageKey(name, lang)] = newStaticAsset(name, localizePage(data, lang))
+			}
+			return nil
+		}
+		s.assets[name] = newStaticAsset(name, data)
+		return nil
+	})
+	return s
+}
+
+func pageKey(name string, lang Lang) string {
+	return name + "@" + string(lang)
+}
+
+// 按请求路径提供 /assets/ 下的资源
+func (s *assetServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	if !strings.HasPrefix(name, "assets/") {
+		http.NotFound(w, r)
+		return
+	}
+	s.serve(w, r, name, assetMaxAge)
+}
+
+// 按 Accept-Language 提供对应语言的页面
+func (s *assetServer) servePage(w http.ResponseWriter, r *http.Request, name string) {
+	lang := requestLang(r)
+	w.Header().Set("Content-Language", T(lang, "web.html_lang"))
+	w.Header().Add("Vary", "Accept-Language")
+	s.serve(w, r, pageKey(name, lang), pageNoCache)
+}
+
+// 提供指定的内置文件
+func (s *assetServer) serve(w http.ResponseWriter, r *http.Request, name, cacheControl string) {
+	asset, ok := s.assets[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", asset.contentType)
+	h.Set("Cache-Control", cacheControl)
+	h.Add("Vary", "Accept-Encoding")
+
+	data, etag := asset.data, asset.etag
+	if asset.gzipped != nil && acceptsGzip(r) {
+		data, etag = asset.gzipped, etag+"-gz"
+		h.Set("Content-Encoding", "gzip")
+	}
+	h.Set("ETag", `"`+etag+`"`)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
+
+// 客户端是否接受 gzip 压缩
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			return strings.ReplaceAll(params, " ", "") != "q=0"
+		}
+	}
+	return false
+}
+
+// 内置的静态资源，首次请求时加载
+var webAssets = sync.OnceValue(func() *assetServer {
+	sub, _ := fs.Sub(assetFS, "static")
+	return newAssetServer(sub)
+})
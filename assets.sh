@@ -1,3 +0,0 @@
-export PATH=$PATH:~/go/bin
-fyne bundle -o upload.go static/upload.html
-fyne bundle -o list.go static/list.html
\ No newline at end of file
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 访问记录的操作
+const (
+	AuditList     = "list"
+	AuditSearch   = "search"
+	AuditDownload = "download"
+	AuditUpload   = "upload"
+	AuditDelete   = "delete"
+	AuditMove     = "move"
+	AuditCopy     = "copy"
+	AuditMkdir    = "mkdir"
+)
+
+// 一条访问记录
+type AuditEntry struct {
+	Time     time.Time `json:"time"`
+	IP       string    `json:"ip"`
+	Device   string    `json:"device"`
+	Action   string    `json:"action"`
+	Path     string    `json:"path"`
+	Bytes    int64     `json:"bytes"`      // 上传或下载的字节数
+	Duration int64     `json:"durationMs"` // 耗时，单位毫秒
+	Status   int       `json:"status"`     // HTTP 状态码
+	Result   string    `json:"result"`     // ok、aborted 或错误码
+
+	counted bool // 处理函数已填写字节数
+}
+
+// 访问记录配置
+type AuditConfig struct {
+	Disabled bool  `json:"disabled"`
+	MaxSize  int64 `json:"maxSize"`  // 单个日志文件的最大字节数，默认 10MB
+	MaxFiles int   `json:"maxFiles"` // 保留的旧日志文件数，默认 5
+}
+
+const (
+	defaultAuditMaxSize  = 10 << 20
+	defaultAuditMaxFiles = 5
+)
+
+// 访问记录，每行一条 JSON，超过大小后轮转为 audit.log.1、audit.log.2……
+type AuditLog struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	// 写入一条记录后调用，桌面端用于刷新列表
+	OnRecord func(AuditEntry)
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// 打开访问记录文件，不存在时创建
+func OpenAuditLog(path string, cfg AuditConfig) (*AuditLog, error) {
+	a := &AuditLog{path: path, maxSize: cfg.MaxSize, maxFiles: cfg.MaxFiles}
+	if a.maxSize <= 0 {
+		a.maxSize = defaultAuditMaxSize
+	}
+	if a.maxFiles <= 0 {
+		a.maxFiles = defaultAuditMaxFiles
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	if err := a.open(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *AuditLog) open() error {
+	f, err := os.OpenFile(a.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	a.file = f
+	a.size = info.Size()
+	return nil
+}
+
+// 第 n 个旧日志文件
+func (a *AuditLog) rotated(n int) string {
+	return a.path + "." + strconv.Itoa(n)
+}
+
+// 轮转日志文件，调用时需持有锁
+func (a *AuditLog) rotate() error {
+	a.file.Close()
+	os.Remove(a.rotated(a.maxFiles))
+	for n := a.maxFiles - 1; n >= 1; n-- {
+		os.Rename(a.rotated(n), a.rotated(n+1))
+	}
+	if err := os.Rename(a.path, a.rotated(1)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Printf("轮转访问记录失败: %v", err)
+	}
+	return a.open()
+}
+
+// 写入一条记录
+func (a *AuditLog) Record(e AuditEntry) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	data = append(data, '\n')
+
+	a.mu.Lock()
+	if a.file != nil && a.size > 0 && a.size+int64(len(data)) > a.maxSize {
+		if err := a.rotate(); err != nil {
+			log.Printf("打开访问记录失败: %v", err)
+			a.file = nil
+		}
+	}
+	if a.file != nil {
+		n, err := a.file.Write(data)
+		a.size += int64(n)
+		if err != nil {
+			log.Printf("写入访问记录失败: %v", err)
+		}
+	}
+	a.mu.Unlock()
+
+	if a.OnRecord != nil {
+		a.OnRecord(e)
+	}
+}
+
+// 读取所有记录，包括轮转的旧文件，按时间从旧到新排列。
+// 只在加锁时打开文件，读取时不阻塞写入，当前文件只读到打开时的长度
+func (a *AuditLog) Entries() ([]AuditEntry, error) {
+	type snapshot struct {
+		f    *os.File
+		size int64 // 当前文件已写入的长度，轮转的旧文件为 -1
+	}
+	a.mu.Lock()
+	names := []string{a.path}
+	for n := 1; n <= a.maxFiles; n++ {
+		names = append([]string{a.rotated(n)}, names...)
+	}
+	var files []snapshot
+	for _, name := range names {
+		f, err := os.Open(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			a.mu.Unlock()
+			for _, s := range files {
+				s.f.Close()
+			}
+			return nil, err
+		}
+		size := int64(-1)
+		if name == a.path && a.file != nil {
+			size = a.size
+		}
+		files = append(files, snapshot{f, size})
+	}
+	a.mu.Unlock()
+
+	var entries []AuditEntry
+	var readErr error
+	for _, s := range files {
+		var r io.Reader = s.f
+		if s.size >= 0 {
+			r = io.LimitReader(s.f, s.size)
+		}
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64<<10), 1<<20)
+		for scanner.Scan() {
+			var e AuditEntry
+			if json.Unmarshal(scanner.Bytes(), &e) == nil {
+				entries = append(entries, e)
+			}
+		}
+		s.f.Close()
+		if err := scanner.Err(); err != nil && readErr == nil {
+			readErr = err
+		}
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	return entries, nil
+}
+
+func (a *AuditLog) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file = nil
+	return err
+}
+
+// 导出为 CSV
+func WriteAuditCSV(w io.Writer, entries []AuditEntry) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"time", "ip", "device", "action", "path", "bytes", "duration_ms", "status", "result"})
+	for _, e := range entries {
+		cw.Write([]string{
+			e.Time.Format(time.RFC3339),
+			e.IP,
+			e.Device,
+			e.Action,
+			e.Path,
+			strconv.FormatInt(e.Bytes, 10),
+			strconv.FormatInt(e.Duration, 10),
+			strconv.Itoa(e.Status),
+			e.Result,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// 导出为 JSON 数组
+func WriteAuditJSON(w io.Writer, entries []AuditEntry) error {
+	if entries == nil {
+		entries = []AuditEntry{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+type auditKey struct{}
+
+// 请求对应的访问记录，处理函数可以补充路径、字节数和错误码，不记录的请求返回 nil
+func auditEntry(r *http.Request) *AuditEntry {
+	e, _ := r.Context().Value(auditKey{}).(*AuditEntry)
+	return e
+}
+
+// 记录实际保存的文件和大小
+func auditUpload(r *http.Request, rel string, n int64) {
+	if e := auditEntry(r); e != nil {
+		e.Path = "/" + rel
+		e.Bytes = n
+		e.counted = true
+	}
+}
+
+// 根据请求判断要记录的操作和路径，页面和其他接口不记录
+func auditAction(r *http.Request) (action, p string) {
+	query := r.URL.Query()
+	switch {
+	case strings.HasPrefix(r.URL.Path, davPrefix+"/"):
+		p = "/" + cleanRelPath(strings.TrimPrefix(r.URL.Path, davPrefix))
+		switch r.Method {
+		case "PROPFIND":
+			return AuditList, p
+		case http.MethodGet:
+			return AuditDownload, p
+		case http.MethodPut:
+			return AuditUpload, p
+		case http.MethodDelete:
+			return AuditDelete, p
+		case "MKCOL":
+			return AuditMkdir, p
+		case "MOVE", "COPY":
+			if u, err := url.Parse(r.Header.Get("Destination")); err == nil {
+				p += " -> /" + cleanRelPath(strings.TrimPrefix(u.Path, davPrefix))
+			}
+			if r.Method == "MOVE" {
+				return AuditMove, p
+			}
+			return AuditCopy, p
+		}
+		return "", ""
+	}
+
+	// 分享链接的下载和收集链接的上传，实际的文件路径由处理函数填写
+	if link, ok := strings.CutPrefix(r.URL.Path, apiV1Prefix+"/links/"); ok {
+		switch {
+		case strings.HasSuffix(link, "/download"):
+			return AuditDownload, linkPrefix + link
+		case strings.HasSuffix(link, "/upload") && r.Method == http.MethodPost:
+			return AuditUpload, linkPrefix + link
+		}
+	}
+
+	p = "/" + cleanRelPath(query.Get("path"))
+	switch strings.TrimPrefix(r.URL.Path, apiV1Prefix) {
+	case "/api/files", "/files":
+		return AuditList, p
+	case "/api/search", "/search":
+		return AuditSearch, p + "?q=" + query.Get("q")
+	case "/download/", "/download":
+		return AuditDownload, p
+	case "/api/upload", "/upload":
+		if r.Method == http.MethodPost {
+			return AuditUpload, p
+		}
+	}
+	return "", ""
+}
+
+// 记录文件的列出、搜索、上传、下载和修改，请求结束后写入访问记录
+func (t *AppServer) auditMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		action, p := auditAction(r)
+		if t.Audit == nil || action == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		dev, _ := t.Devices.Get(deviceID(r))
+		e := &AuditEntry{
+			Time:   start,
+			IP:     clientIP(r),
+			Device: dev.DisplayName(),
+			Action: action,
+			Path:   p,
+		}
+		var in, out int64
+		if r.Body != nil {
+			r.Body = &countingReader{r: r.Body, count: func(n int64) { in += n }}
+		}
+		sw := &statusWriter{countingWriter: countingWriter{ResponseWriter: w, count: func(n int64) { out += n }}}
+		r = r.WithContext(context.WithValue(r.Context(), auditKey{}, e))
+		next.ServeHTTP(sw, r)
+
+		e.Duration = time.Since(start).Milliseconds()
+		e.Status = sw.code()
+		if !e.counted {
+			if action == AuditUpload {
+				e.Bytes = in
+			} else if action == AuditDownload {
+				e.Bytes = out
+			}
+		}
+		switch {
+		case e.Result != "":
+		case e.Status >= 400:
+			e.Result = fmt.Sprintf("http_%d", e.Status)
+		case r.Context().Err() != nil:
+			e.Result = "aborted"
+		default:
+			e.Result = "ok"
+		}
+		t.Audit.Record(*e)
+	})
+}
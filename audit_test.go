@@ -0,0 +1,54 @@
+package main
+
+import (
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestAuditEntriesWhileRecording(t *testing.T) {
+	audit, err := OpenAuditLog(filepath.Join(t.TempDir(), "audit.log"), AuditConfig{MaxSize: 2000, MaxFiles: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer audit.Close()
+
+	// 读取的同时写入和轮转，每次读到的记录都按顺序且不重复
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			entries, err := audit.Entries()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			for i := 1; i < len(entries); i++ {
+				prev, _ := strconv.Atoi(entries[i-1].Path)
+				cur, _ := strconv.Atoi(entries[i].Path)
+				if cur != prev+1 {
+					t.Errorf("entry %d follows %d", cur, prev)
+					return
+				}
+			}
+		}
+	}()
+	for i := range 300 {
+		audit.Record(AuditEntry{Action: "download", Path: strconv.Itoa(i)})
+	}
+	close(done)
+	wg.Wait()
+
+	entries, err := audit.Entries()
+	if err != nil || len(entries) == 0 || entries[len(entries)-1].Path != "299" {
+		t.Fatalf("%d entries, %v", len(entries), err)
+	}
+}
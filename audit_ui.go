@@ -0,0 +1,166 @@
+package main
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 访问记录列表最多显示的条数，导出时包含全部记录
+const auditViewLimit = 1000
+
+// 访问记录，可按关键字筛选并导出为 CSV 或 JSON
+func createAuditPanel(window fyne.Window, state *AppState) fyne.CanvasObject {
+	if state.Audit == nil {
+		return container.NewCenter(widget.NewLabel(tr("ui.audit_disabled")))
+	}
+
+	var entries []AuditEntry // 从新到旧
+	var shown []AuditEntry
+	filter := widget.NewEntry()
+	filter.SetPlaceHolder(tr("ui.audit_filter"))
+
+	headers := []string{
+		tr("ui.audit_time"), tr("ui.audit_ip"), tr("ui.device_name"), tr("ui.audit_action"),
+		tr("ui.audit_path"), tr("ui.audit_bytes"), tr("ui.audit_duration"), tr("ui.audit_result"),
+	}
+	widths := []float32{150, 110, 120, 70, 260, 90, 70, 120}
+
+	table := widget.NewTableWithHeaders(
+		func() (int, int) { return len(shown), len(headers) },
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(auditCell(shown[id.Row], id.Col))
+		},
+	)
+	table.ShowHeaderColumn = false
+	table.CreateHeader = func() fyne.CanvasObject {
+		return widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	}
+	table.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+		if id.Col >= 0 {
+			o.(*widget.Label).SetText(headers[id.Col])
+		}
+	}
+	for i, w := range widths {
+		table.SetColumnWidth(i, w)
+	}
+
+	applyFilter := func() {
+		q := strings.ToLower(strings.TrimSpace(filter.Text))
+		shown = shown[:0]
+		for _, e := range entries {
+			if q == "" || auditMatches(e, q) {
+				shown = append(shown, e)
+			}
+		}
+		table.Refresh()
+	}
+	filter.OnChanged = func(string) { applyFilter() }
+
+	reload := func() {
+		all, err := state.Audit.Entries()
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if len(all) > auditViewLimit {
+			all = all[len(all)-auditViewLimit:]
+		}
+		slices.Reverse(all)
+		entries = all
+		applyFilter()
+	}
+	reload()
+
+	// 新记录插入到最前面
+	state.Audit.OnRecord = func(e AuditEntry) {
+		fyne.Do(func() {
+			entries = append([]AuditEntry{e}, entries...)
+			if len(entries) > auditViewLimit {
+				entries = entries[:auditViewLimit]
+			}
+			applyFilter()
+		})
+	}
+
+	export := func(name string, write func(io.Writer, []AuditEntry) error) {
+		all, err := state.Audit.Entries()
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		save := dialog.NewFileSave(func(w fyne.URIWriteCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			if w == nil {
+				return
+			}
+			defer w.Close()
+			if err := write(w, all); err != nil {
+				dialog.ShowError(err, window)
+				return
+			}
+			showToast(tr("ui.audit_exported"), window)
+		}, window)
+		save.SetFileName(name)
+		save.Show()
+	}
+
+	toolbar := container.NewBorder(nil, nil, nil,
+		container.NewHBox(
+			widget.NewButton(tr("ui.refresh"), reload),
+			widget.NewButton(tr("ui.audit_export_csv"), func() { export("audit.csv", WriteAuditCSV) }),
+			widget.NewButton(tr("ui.audit_export_json"), func() { export("audit.json", WriteAuditJSON) }),
+		),
+		filter,
+	)
+	return container.NewBorder(toolbar, nil, nil, nil, table)
+}
+
+// 表格中第 col 列的文本
+func auditCell(e AuditEntry, col int) string {
+	switch col {
+	case 0:
+		return e.Time.Format("2006-01-02 15:04:05")
+	case 1:
+		return e.IP
+	case 2:
+		return e.Device
+	case 3:
+		return tr("ui.audit_" + e.Action)
+	case 4:
+		return e.Path
+	case 5:
+		return formatFileSize(e.Bytes)
+	case 6:
+		return (time.Duration(e.Duration) * time.Millisecond).String()
+	default:
+		if e.Result == "ok" {
+			return tr("ui.audit_ok")
+		}
+		return e.Result
+	}
+}
+
+// 任意一列包含关键字
+func auditMatches(e AuditEntry, q string) bool {
+	for col := range 8 {
+		if strings.Contains(strings.ToLower(auditCell(e, col)), q) {
+			return true
+		}
+	}
+	return strings.Contains(e.Action, q)
+}
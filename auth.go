@@ -0,0 +1,90 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// 访问共享需要的账号密码，使用 Basic 认证，浏览器和网络驱动器都会弹出登录框。
+// 配置中只保存加盐的 PBKDF2 哈希，与分享链接的密码相同
+type AuthConfig struct {
+	Username     string `json:"username"`     // 为空时任意用户名都可以
+	PasswordHash string `json:"passwordHash"` // 为空表示不需要认证
+	Salt         string `json:"salt"`
+}
+
+// 用新密码生成认证设置，密码为空时不需要认证
+func NewAuthConfig(username, password string) AuthConfig {
+	c := AuthConfig{Username: username}
+	if password != "" {
+		salt := make([]byte, 16)
+		rand.Read(salt)
+		c.Salt = hex.EncodeToString(salt)
+		c.PasswordHash = hashLinkPassword(password, salt)
+	}
+	return c
+}
+
+// 是否需要认证
+func (c AuthConfig) enabled() bool {
+	return c.PasswordHash != ""
+}
+
+// 验证通过的密码，避免每个请求都重新计算 PBKDF2。键为密码哈希，值为账号密码的 SHA-256
+var authVerified sync.Map
+
+// 请求是否通过认证
+func (c AuthConfig) authorized(r *http.Request) bool {
+	user, pass, ok := r.BasicAuth()
+	if !ok || !c.enabled() {
+		return false
+	}
+	if c.Username != "" && subtle.ConstantTimeCompare([]byte(user), []byte(c.Username)) != 1 {
+		return false
+	}
+	return verifyPassword(pass, c.Salt, c.PasswordHash)
+}
+
+// 密码是否与加盐的哈希一致，验证通过后记住结果
+func verifyPassword(pass, salt, hash string) bool {
+	sum := sha256.Sum256([]byte(salt + "\x00" + pass))
+	if v, ok := authVerified.Load(hash); ok && subtle.ConstantTimeCompare(v.([]byte), sum[:]) == 1 {
+		return true
+	}
+	b, err := hex.DecodeString(salt)
+	if err != nil || subtle.ConstantTimeCompare([]byte(hashLinkPassword(pass, b)), []byte(hash)) != 1 {
+		return false
+	}
+	authVerified.Store(hash, sum[:])
+	return true
+}
+
+// 不需要认证的路径：分享链接有自己的密码，页面样式和停止共享的通知不涉及文件
+func authExempt(r *http.Request) bool {
+	p := r.URL.Path
+	return r.Method == http.MethodOptions ||
+		strings.HasPrefix(p, linkPrefix) ||
+		strings.HasPrefix(p, apiV1Prefix+"/links/") ||
+		strings.HasPrefix(p, "/assets/") ||
+		p == apiV1Prefix+"/events"
+}
+
+// 设置密码后要求认证，未通过时返回 401
+func (c AuthConfig) Middleware(next http.Handler) http.Handler {
+	if !c.enabled() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if authExempt(r) || c.authorized(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("WWW-Authenticate", `Basic realm="kuaichuan", charset="UTF-8"`)
+		writeError(w, r, http.StatusUnauthorized, "auth_required")
+	})
+}
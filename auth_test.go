@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAuthPasswordHashed(t *testing.T) {
+	c := NewAuthConfig("me", "hunter2")
+	data, _ := json.Marshal(c)
+	if strings.Contains(string(data), "hunter2") {
+		t.Fatalf("password saved in plain text: %s", data)
+	}
+
+	login := func(user, pass string) bool {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.SetBasicAuth(user, pass)
+		return c.authorized(r)
+	}
+	// 第二次使用验证过的缓存
+	for range 2 {
+		if !login("me", "hunter2") {
+			t.Fatal("correct password rejected")
+		}
+	}
+	if login("me", "wrong") || login("other", "hunter2") {
+		t.Fatal("wrong credentials accepted")
+	}
+	if NewAuthConfig("", "hunter2").PasswordHash == c.PasswordHash {
+		t.Fatal("same hash for a different salt")
+	}
+	if NewAuthConfig("me", "").enabled() {
+		t.Fatal("empty password enables auth")
+	}
+}
+
+func TestConfigMigratesPlainPassword(t *testing.T) {
+	c, err := parseConfig([]byte(`{"version": 3, "auth": {"username": "me", "password": "hunter2"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Auth.Username != "me" || !c.Auth.enabled() {
+		t.Fatalf("auth = %+v", c.Auth)
+	}
+	r := httptest.NewRequest("GET", "/", nil)
+	r.SetBasicAuth("me", "hunter2")
+	if !c.Auth.authorized(r) {
+		t.Fatal("migrated password rejected")
+	}
+	if data, _ := json.Marshal(c); strings.Contains(string(data), "hunter2") {
+		t.Fatalf("password kept in plain text: %s", data)
+	}
+
+	c, _ = parseConfig([]byte(`{"version": 3, "auth": {"username": "", "password": ""}}`))
+	if c.Auth.enabled() || c.Validate() != nil {
+		t.Fatalf("empty password: %+v", c.Auth)
+	}
+}
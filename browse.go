@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+)
+
+// 超过该大小的图片不生成缩略图
+const thumbnailMaxSize = 20 << 20
+
+// 生成缩略图时最多解码的像素数，文件很小但声明了巨大尺寸的图片会占用大量内存
+const thumbnailMaxPixels = 40_000_000
+
+var errImageTooLarge = errors.New("图片尺寸过大")
+
+// 可以生成缩略图的图片
+func isImageFile(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".png", ".jpg", ".jpeg", ".gif":
+		return true
+	}
+	return false
+}
+
+// 把图片缩小到不超过 size×size，编码为 PNG。先读取图片尺寸，超过 thumbnailMaxPixels 的不解码
+func makeThumbnail(r io.Reader, size int) ([]byte, error) {
+	var head bytes.Buffer
+	config, _, err := image.DecodeConfig(io.TeeReader(r, &head))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width <= 0 || config.Height <= 0 || int64(config.Width)*int64(config.Height) > thumbnailMaxPixels {
+		return nil, errImageTooLarge
+	}
+	src, _, err := image.Decode(io.MultiReader(&head, r))
+	if err != nil {
+		return nil, err
+	}
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > size || h > size {
+		if w >= h {
+			w, h = size, max(h*size/w, 1)
+		} else {
+			w, h = max(w*size/h, 1), size
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			dst.Set(x, y, src.At(b.Min.X+x*b.Dx()/w, b.Min.Y+y*b.Dy()/h))
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, dst); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// 重命名时输入的名称是否可用，不能包含路径分隔符
+func validFileName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// 删除文件或目录，skip 返回 true 的文件会保留，目录中有保留的文件时删除失败
+func removeTree(share Storage, rel string, skip func(rel string, isDir bool) bool) error {
+	info, err := share.Stat(rel)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return share.Remove(rel)
+	}
+
+	var entries []string
+	share.Walk(rel, func(p string, info fs.FileInfo) error {
+		if skip != nil && skip(p, info.IsDir()) {
+			if info.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		entries = append(entries, p)
+		return nil
+	})
+	// 先删除深层的文件，再删除上级目录
+	slices.SortFunc(entries, func(a, b string) int {
+		return strings.Count(b, "/") - strings.Count(a, "/")
+	})
+	for _, p := range append(entries, rel) {
+		if err := share.Remove(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 重命名后更新上传历史中的路径，保留文件的来源
+func renameInHistory(share Storage, from, to string) error {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+	history, err := readHistoryFile(share)
+	if err != nil {
+		return err
+	}
+	changed := false
+	for i, f := range history {
+		switch {
+		case f.Name == from:
+			history[i].Name = to
+		case strings.HasPrefix(f.Name, from+"/"):
+			history[i].Name = to + strings.TrimPrefix(f.Name, from)
+		default:
+			continue
+		}
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return writeHistoryFile(share, history)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestMakeThumbnail(t *testing.T) {
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 300, 150)), nil)
+	data, err := makeThumbnail(&buf, 80)
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumb, err := png.Decode(bytes.NewReader(data))
+	if err != nil || thumb.Bounds().Dx() != 80 || thumb.Bounds().Dy() != 40 {
+		t.Fatalf("thumbnail: %v %v", err, thumb.Bounds())
+	}
+
+	// 小图片保持原来的尺寸
+	buf.Reset()
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 10, 20)))
+	data, _ = makeThumbnail(&buf, 80)
+	if thumb, _ = png.Decode(bytes.NewReader(data)); thumb.Bounds().Dx() != 10 || thumb.Bounds().Dy() != 20 {
+		t.Fatalf("small image resized to %v", thumb.Bounds())
+	}
+
+	if _, err := makeThumbnail(bytes.NewReader([]byte("not an image")), 80); err == nil {
+		t.Fatal("decoded garbage")
+	}
+}
+
+func TestMakeThumbnailRejectsHugeDimensions(t *testing.T) {
+	// 只有几十字节的 GIF，声明 65535×65535 的画布
+	var buf bytes.Buffer
+	gif.Encode(&buf, image.NewPaletted(image.Rect(0, 0, 1, 1), []color.Color{color.Black}), nil)
+	data := buf.Bytes()
+	copy(data[6:10], []byte{0xff, 0xff, 0xff, 0xff})
+	if _, err := makeThumbnail(bytes.NewReader(data), 80); !errors.Is(err, errImageTooLarge) {
+		t.Fatalf("err = %v, want errImageTooLarge", err)
+	}
+}
@@ -1,5 +1,4 @@
 export PATH=$PATH:~/go/bin
-sh assets.sh
 rm -rf release/kuaichuan.app
 rm -rf release/kuaichuan.dmg
 fyne package -os darwin -name release/kuaichuan -icon Icon.png -src .
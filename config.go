@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// 配置文件的版本，修改结构时加一，并在 configMigrations 中添加旧版本的迁移
+const configVersion = 5
+
+// 配置文件的内容
+type Config struct {
+	Version         int           `json:"version"`
+	UploadDir       string        `json:"uploadDir"`
+	RecentFolders   []string      `json:"recentFolders"`   // 最近共享的文件夹，最近的在前
+	FavoriteFolders []string      `json:"favoriteFolders"` // 收藏的文件夹
+	AutoStart       bool          `json:"autoStart"`       // 启动时恢复上次退出时的共享
+	WasSharing      bool          `json:"wasSharing"`      // 上次退出时是否正在共享
+	Port            int           `json:"port"`
+	BindAddress     string        `json:"bindAddress"` // 为空时监听所有网卡
+	Mode            ShareMode     `json:"mode"`
+	RequireApproval bool          `json:"requireApproval"`
+	FollowSymlinks  bool          `json:"followSymlinks"`
+	Auth            AuthConfig    `json:"auth"`
+	Limits          UploadLimits  `json:"limits"`
+	Throttle        ThrottleRates `json:"throttle"`
+	Storage         StorageConfig `json:"storage"`
+	CORS            CORSConfig    `json:"cors"`
+	Audit           AuditConfig   `json:"audit"`
+	Metrics         MetricsConfig `json:"metrics"`
+	UI              UIConfig      `json:"ui"`
+	Devices         []SavedDevice `json:"devices"`
+	DeviceKey       string        `json:"deviceKey"` // 签名设备 cookie 的密钥，为空时重新生成
+	Links           []ShareLink   `json:"links"`
+}
+
+// 桌面端的界面设置
+type UIConfig struct {
+	Language       string   `json:"language"`       // zh 或 en，为空时跟随系统
+	MinimizeToTray bool     `json:"minimizeToTray"` // 关闭窗口时隐藏到系统托盘
+	MutedShares    []string `json:"mutedShares"`    // 收到文件时不通知的共享文件夹
+}
+
+func defaultConfig() Config {
+	return Config{Version: configVersion, Port: defaultPort}
+}
+
+// 各版本的迁移，configMigrations[i] 把第 i+1 版的配置升级到第 i+2 版
+var configMigrations = []func(m map[string]any){
+	// 1 → 2：没有版本号的旧配置，界面设置移到 ui 中，端口使用原来固定的 8000
+	func(m map[string]any) {
+		ui := make(map[string]any)
+		for _, key := range []string{"language", "minimizeToTray", "mutedShares"} {
+			if v, ok := m[key]; ok {
+				ui[key] = v
+				delete(m, key)
+			}
+		}
+		m["ui"] = ui
+		m["port"] = defaultPort
+	},
+	// 2 → 3：新增最近的文件夹，加入当前的共享文件夹
+	func(m map[string]any) {
+		if dir, ok := m["uploadDir"].(string); ok && dir != "" {
+			m["recentFolders"] = []any{dir}
+		}
+	},
+	// 3 → 4：访问密码不再明文保存，改为加盐的哈希
+	func(m map[string]any) {
+		auth, ok := m["auth"].(map[string]any)
+		if !ok {
+			return
+		}
+		password, _ := auth["password"].(string)
+		username, _ := auth["username"].(string)
+		c := NewAuthConfig(username, password)
+		delete(auth, "password")
+		auth["passwordHash"] = c.PasswordHash
+		auth["salt"] = c.Salt
+	},
+	// 4 → 5：指标的密码和令牌也改为哈希
+	hashMetricsSecrets,
+}
+
+// 把配置中明文的指标密码和令牌换成哈希，手动编辑配置时也可以填写明文
+func hashMetricsSecrets(m map[string]any) {
+	metrics, ok := m["metrics"].(map[string]any)
+	if !ok {
+		return
+	}
+	password, _ := metrics["password"].(string)
+	token, _ := metrics["token"].(string)
+	delete(metrics, "password")
+	delete(metrics, "token")
+	var c MetricsConfig
+	c.PasswordHash, _ = metrics["passwordHash"].(string)
+	c.TokenHash, _ = metrics["tokenHash"].(string)
+	c.Salt, _ = metrics["salt"].(string)
+	c.setSecrets(password, token)
+	metrics["passwordHash"] = c.PasswordHash
+	metrics["tokenHash"] = c.TokenHash
+	metrics["salt"] = c.Salt
+}
+
+// 最多记住的最近文件夹数
+const maxRecentFolders = 8
+
+// 把文件夹移到最近列表的最前面
+func addRecentFolder(recent []string, dir string) []string {
+	recent = slices.DeleteFunc(slices.Clone(recent), func(d string) bool { return d == dir })
+	recent = slices.Insert(recent, 0, dir)
+	return recent[:min(len(recent), maxRecentFolders)]
+}
+
+// 解析配置文件，旧版本先迁移到当前版本
+func parseConfig(data []byte) (Config, error) {
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return defaultConfig(), err
+	}
+	version := 1
+	if v, ok := m["version"].(float64); ok {
+		version = int(v)
+	}
+	if version < 1 || version > configVersion {
+		return defaultConfig(), errors.New(tr("ui.config_version", version))
+	}
+	for ; version < configVersion; version++ {
+		configMigrations[version-1](m)
+	}
+	m["version"] = configVersion
+	hashMetricsSecrets(m)
+
+	data, _ = json.Marshal(m)
+	c := defaultConfig()
+	if err := json.Unmarshal(data, &c); err != nil {
+		return defaultConfig(), err
+	}
+	return c, nil
+}
+
+// 读取配置文件，文件不存在时返回默认配置
+func LoadConfig(name string) (Config, error) {
+	data, err := os.ReadFile(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return defaultConfig(), nil
+	}
+	if err != nil {
+		return defaultConfig(), err
+	}
+	return parseConfig(data)
+}
+
+// 保存配置，先写入临时文件再替换，写到一半时退出不会损坏原来的配置
+func (c Config) Save(name string) error {
+	c.Version = configVersion
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(name, data, 0600)
+}
+
+// 写入文件，先写入同目录的临时文件，同步到磁盘后重命名
+func writeFileAtomic(name string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp, name)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// 检查配置，返回所有不正确的设置
+func (c Config) Validate() error {
+	return c.check(false)
+}
+
+// 把不正确的设置恢复为默认值，返回修正了哪些设置
+func (c *Config) Repair() error {
+	return c.check(true)
+}
+
+func (c *Config) check(repair bool) error {
+	var errs []error
+	invalid := func(bad bool, reset func(), id string, args ...any) {
+		if !bad {
+			return
+		}
+		errs = append(errs, errors.New(tr(id, args...)))
+		if repair {
+			reset()
+		}
+	}
+	def := defaultConfig()
+
+	invalid(c.Port < 1 || c.Port > 65535, func() { c.Port = def.Port }, "ui.config_port", c.Port)
+	invalid(c.BindAddress != "" && net.ParseIP(c.BindAddress) == nil,
+		func() { c.BindAddress = "" }, "ui.config_bind", c.BindAddress)
+	invalid(!slices.Contains([]ShareMode{ModeReadWrite, ModeReadOnly, ModeUploadOnly}, c.Mode),
+		func() { c.Mode = def.Mode }, "ui.config_mode", c.Mode)
+	invalid(c.Auth.Username != "" && !c.Auth.enabled(),
+		func() { c.Auth = AuthConfig{} }, "ui.config_auth")
+	l := c.Limits
+	invalid(l.MaxFileSize < 0 || l.ClientQuota < 0 || l.ShareQuota < 0 || l.MinFreeSpace < 0,
+		func() { c.Limits = def.Limits }, "ui.config_limits")
+	rates := c.Throttle
+	invalid(rates.Upload < 0 || rates.Download < 0 || rates.ClientUpload < 0 || rates.ClientDownload < 0,
+		func() { c.Throttle = def.Throttle }, "ui.config_throttle")
+	for ip, rate := range rates.Clients {
+		invalid(net.ParseIP(ip) == nil || rate.Upload < 0 || rate.Download < 0,
+			func() { delete(c.Throttle.Clients, ip) }, "ui.config_throttle_client", ip)
+	}
+	invalid(!slices.Contains([]string{"", "local", "s3"}, c.Storage.Type),
+		func() { c.Storage = def.Storage }, "ui.config_storage", c.Storage.Type)
+	invalid(c.Storage.Type == "s3" && c.Storage.S3.Bucket == "",
+		func() { c.Storage = def.Storage }, "ui.config_s3_bucket")
+	invalid(c.CORS.MaxAge < 0, func() { c.CORS.MaxAge = 0 }, "ui.config_cors")
+	invalid(c.Audit.MaxSize < 0 || c.Audit.MaxFiles < 0,
+		func() { c.Audit.MaxSize, c.Audit.MaxFiles = 0, 0 }, "ui.config_audit")
+	_, langOK := parseLang(c.UI.Language)
+	invalid(c.UI.Language != "" && !langOK, func() { c.UI.Language = "" }, "ui.config_language", c.UI.Language)
+	return errors.Join(errs...)
+}
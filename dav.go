@@ -0,0 +1,475 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/net/webdav"
+)
+
+// WebDAV 访问地址，可在文件管理器中映射为网络驱动器
+const davPrefix = "/dav"
+
+// 修改共享文件夹的 WebDAV 方法
+var davWriteMethods = []string{"PUT", "MKCOL", "DELETE", "MOVE", "COPY", "PROPPATCH", "LOCK", "UNLOCK"}
+
+// WebDAV 处理函数，与网页接口使用相同的忽略规则、共享模式、上传确认和上传限制
+func (t *AppServer) davHandler() http.Handler {
+	h := &webdav.Handler{
+		Prefix:     davPrefix,
+		FileSystem: &davFS{t: t},
+		LockSystem: webdav.NewMemLS(),
+		Logger: func(r *http.Request, err error) {
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				log.Printf("WebDAV %s %s 失败: %v", r.Method, r.URL.Path, err)
+			}
+		},
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !t.checkDAV(w, r) {
+			return
+		}
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), davRequestKey{}, r)))
+	})
+}
+
+// 在交给 webdav 处理前检查共享模式、隐藏路径、上传限制和上传确认，返回是否继续
+func (t *AppServer) checkDAV(w http.ResponseWriter, r *http.Request) bool {
+	rel := cleanRelPath(strings.TrimPrefix(r.URL.Path, davPrefix))
+	mode := t.shareMode()
+
+	switch {
+	case r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodPost || r.Method == "PROPFIND":
+		// 仅上传模式下只能访问和列出根目录，以便客户端确认连接，根目录列出时为空
+		if !mode.canRead() && rel != "" {
+			writeError(w, r, http.StatusForbidden, "share_upload_only")
+			return false
+		}
+		return true
+	case !slices.Contains(davWriteMethods, r.Method):
+		return true // OPTIONS
+	}
+
+	if !mode.canWrite() {
+		writeError(w, r, http.StatusForbidden, "share_read_only")
+		return false
+	}
+	// 仅上传模式下不能改动已有的文件
+	if !mode.canRead() {
+		switch r.Method {
+		case "DELETE", "MOVE", "COPY":
+			writeError(w, r, http.StatusForbidden, "share_upload_only")
+			return false
+		case "PUT":
+			if share, err := t.storage(); err == nil {
+				if _, err := share.Stat(rel); err == nil {
+					writeError(w, r, http.StatusForbidden, "share_upload_only")
+					return false
+				}
+			}
+		}
+	}
+
+	// 目标路径，MOVE 和 COPY 还需要检查 Destination
+	targets := []string{rel}
+	if dst := r.Header.Get("Destination"); dst != "" {
+		if u, err := url.Parse(dst); err == nil {
+			targets = append(targets, cleanRelPath(strings.TrimPrefix(u.Path, davPrefix)))
+		}
+	}
+	for _, p := range targets {
+		if p == "" && r.Method != "PROPPATCH" && r.Method != "LOCK" && r.Method != "UNLOCK" {
+			writeError(w, r, http.StatusForbidden, "upload_forbidden_path")
+			return false
+		}
+		if t.isHidden(p, false) || t.isHidden(p, true) {
+			writeError(w, r, http.StatusForbidden, "upload_forbidden_path")
+			return false
+		}
+	}
+
+	// 根据请求声明的大小预先检查限制
+	if r.Method == "PUT" && r.ContentLength > 0 {
+		allowance := t.uploadAllowance(clientIP(r))
+		if !allowance.allows(r.ContentLength) {
+			writeError(w, r, allowance.Status, allowance.Error, allowance.Args...)
+			return false
+		}
+	}
+
+	// 锁只是配合客户端写入，不需要确认
+	if r.Method == "LOCK" || r.Method == "UNLOCK" {
+		return true
+	}
+	var size int64
+	if r.Method == "PUT" {
+		size = max(r.ContentLength, 0)
+	}
+	result := t.requestApproval(r.Context(), t.newUploadRequest(r, []UploadFile{{Name: targets[len(targets)-1], Size: size}}))
+	return writeApprovalResult(w, r, result)
+}
+
+type davRequestKey struct{}
+
+// 把共享文件夹的存储适配为 webdav.FileSystem，name 为 /dav 之后的路径
+type davFS struct {
+	t *AppServer
+}
+
+func davPathError(op, name string, err error) error {
+	return &fs.PathError{Op: op, Path: name, Err: err}
+}
+
+func (f *davFS) Mkdir(ctx context.Context, name string, perm os.FileMode) error {
+	rel := cleanRelPath(name)
+	if rel == "" {
+		return davPathError("mkdir", name, fs.ErrExist)
+	}
+	if f.t.isHidden(rel, true) {
+		return davPathError("mkdir", name, fs.ErrPermission)
+	}
+	share, err := f.t.storage()
+	if err != nil {
+		return err
+	}
+	if _, err := share.Stat(rel); err == nil {
+		return davPathError("mkdir", name, fs.ErrExist)
+	}
+	if parent := parentDir(rel); parent != "" {
+		if info, err := share.Stat(parent); err != nil || !info.IsDir() {
+			return davPathError("mkdir", name, fs.ErrNotExist)
+		}
+	}
+	return share.MkdirAll(rel)
+}
+
+func (f *davFS) OpenFile(ctx context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	rel := cleanRelPath(name)
+	share, err := f.t.storage()
+	if err != nil {
+		return nil, err
+	}
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC) != 0 {
+		return f.create(ctx, share, rel)
+	}
+
+	info, err := f.Stat(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return &davDir{fs: f, share: share, rel: rel, info: info}, nil
+	}
+	return &davFile{share: share, rel: rel, info: davFileInfo{info, f.t.shareMode().canRead()}}, nil
+}
+
+// 创建文件，写入时按上传限制计数，关闭时替换原文件并记录上传历史
+func (f *davFS) create(ctx context.Context, share Storage, rel string) (webdav.File, error) {
+	if rel == "" {
+		return nil, davPathError("create", rel, fs.ErrInvalid)
+	}
+	if f.t.isHidden(rel, false) {
+		return nil, davPathError("create", rel, fs.ErrPermission)
+	}
+	if parent := parentDir(rel); parent != "" {
+		if info, err := share.Stat(parent); err != nil || !info.IsDir() {
+			return nil, davPathError("create", rel, fs.ErrNotExist)
+		}
+	}
+	if info, err := share.Stat(rel); err == nil {
+		if info.IsDir() {
+			return nil, davPathError("create", rel, fs.ErrExist)
+		}
+		if !f.t.shareMode().canRead() {
+			return nil, davPathError("create", rel, fs.ErrPermission)
+		}
+	}
+
+	w := &davWriter{t: f.t, share: share, rel: rel, part: uploadPartName(rel), allowance: uploadAllowance{Bytes: -1}}
+	if r, ok := ctx.Value(davRequestKey{}).(*http.Request); ok {
+		w.ip = clientIP(r)
+		w.device = f.t.deviceName(r)
+		w.upload = r.Method == "PUT"
+		w.allowance = f.t.uploadAllowance(w.ip)
+	}
+	// 先写入临时文件，关闭时才替换原文件
+	dst, err := share.Create(w.part)
+	if err != nil {
+		return nil, err
+	}
+	w.dst = dst
+	return w, nil
+}
+
+// 删除文件或目录，目录中被忽略的文件会保留
+func (f *davFS) RemoveAll(ctx context.Context, name string) error {
+	rel := cleanRelPath(name)
+	if rel == "" {
+		return davPathError("remove", name, fs.ErrPermission)
+	}
+	share, err := f.t.storage()
+	if err != nil {
+		return err
+	}
+	if _, err := f.Stat(ctx, name); err != nil {
+		return err
+	}
+	return removeTree(share, rel, f.t.isHidden)
+}
+
+func (f *davFS) Rename(ctx context.Context, oldName, newName string) error {
+	from, to := cleanRelPath(oldName), cleanRelPath(newName)
+	if from == "" || to == "" {
+		return davPathError("rename", oldName, fs.ErrInvalid)
+	}
+	info, err := f.Stat(ctx, oldName)
+	if err != nil {
+		return err
+	}
+	if f.t.isHidden(to, info.IsDir()) {
+		return davPathError("rename", newName, fs.ErrPermission)
+	}
+	share, err := f.t.storage()
+	if err != nil {
+		return err
+	}
+	return share.Rename(from, to)
+}
+
+// 被忽略的文件当作不存在，仅上传模式下只能查看根目录，与下载的规则相同
+func (f *davFS) Stat(ctx context.Context, name string) (os.FileInfo, error) {
+	rel := cleanRelPath(name)
+	if rel != "" && !f.t.shareMode().canRead() {
+		return nil, davPathError("stat", name, fs.ErrPermission)
+	}
+	share, err := f.t.storage()
+	if err != nil {
+		return nil, err
+	}
+	info, err := share.Stat(rel)
+	if err != nil {
+		return nil, err
+	}
+	if rel != "" && f.t.isHidden(rel, info.IsDir()) {
+		return nil, davPathError("stat", name, fs.ErrNotExist)
+	}
+	return info, nil
+}
+
+// 只读的文件，读取内容时才打开，列出属性时不需要访问文件内容
+type davFile struct {
+	share Storage
+	rel   string
+	info  davFileInfo
+	file  StorageFile
+}
+
+func (f *davFile) open() error {
+	if f.file != nil {
+		return nil
+	}
+	if !f.info.readable {
+		return davPathError("open", f.rel, fs.ErrPermission)
+	}
+	file, err := f.share.Open(f.rel)
+	if err != nil {
+		return err
+	}
+	f.file = file
+	return nil
+}
+
+func (f *davFile) Read(p []byte) (int, error) {
+	if err := f.open(); err != nil {
+		return 0, err
+	}
+	return f.file.Read(p)
+}
+
+func (f *davFile) Seek(offset int64, whence int) (int64, error) {
+	if err := f.open(); err != nil {
+		return 0, err
+	}
+	return f.file.Seek(offset, whence)
+}
+
+func (f *davFile) Close() error {
+	if f.file != nil {
+		return f.file.Close()
+	}
+	return nil
+}
+
+func (f *davFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+func (f *davFile) Readdir(count int) ([]fs.FileInfo, error) {
+	return nil, davPathError("readdir", f.rel, fs.ErrInvalid)
+}
+
+func (f *davFile) Write(p []byte) (int, error) {
+	return 0, davPathError("write", f.rel, fs.ErrPermission)
+}
+
+// 文件属性，优先按扩展名判断类型，仅上传模式下无法读取内容时不再检测
+type davFileInfo struct {
+	fs.FileInfo
+	readable bool
+}
+
+func (i davFileInfo) ContentType(ctx context.Context) (string, error) {
+	if ctype := mime.TypeByExtension(path.Ext(i.Name())); ctype != "" {
+		return ctype, nil
+	}
+	if i.readable {
+		return "", webdav.ErrNotImplemented
+	}
+	return "application/octet-stream", nil
+}
+
+// 目录，列出时去掉被忽略的文件，仅上传模式下为空
+type davDir struct {
+	fs      *davFS
+	share   Storage
+	rel     string
+	info    fs.FileInfo
+	entries []fs.FileInfo
+	read    bool
+}
+
+func (d *davDir) Readdir(count int) ([]fs.FileInfo, error) {
+	if !d.read {
+		d.read = true
+		if d.fs.t.shareMode().canRead() {
+			infos, err := d.share.List(d.rel)
+			if err != nil {
+				return nil, err
+			}
+			for _, info := range infos {
+				if !d.fs.t.isHidden(path.Join(d.rel, info.Name()), info.IsDir()) {
+					d.entries = append(d.entries, info)
+				}
+			}
+		}
+	}
+	if count <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	n := min(count, len(d.entries))
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
+
+func (d *davDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *davDir) Close() error               { return nil }
+
+func (d *davDir) Read(p []byte) (int, error) {
+	return 0, davPathError("read", d.rel, fs.ErrInvalid)
+}
+
+func (d *davDir) Seek(offset int64, whence int) (int64, error) {
+	return 0, davPathError("seek", d.rel, fs.ErrInvalid)
+}
+
+func (d *davDir) Write(p []byte) (int, error) {
+	return 0, davPathError("write", d.rel, fs.ErrInvalid)
+}
+
+var errDAVUploadLimit = errors.New("超出上传限制")
+
+// 正在写入的文件，超出额度时停止写入，关闭时删除临时文件，原文件不受影响
+type davWriter struct {
+	t         *AppServer
+	share     Storage
+	rel       string
+	part      string // 写入的临时文件
+	dst       io.WriteCloser
+	ip        string
+	device    string
+	upload    bool // 是否为 PUT 上传，记录到上传历史
+	allowance uploadAllowance
+	written   int64
+	reserved  int64 // 已预留的客户端额度
+	err       error
+}
+
+func (w *davWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	if !w.allowance.allows(w.written + int64(len(p))) {
+		w.err = errDAVUploadLimit
+		return 0, w.err
+	}
+	// 上传在写入前预留客户端额度，与其他上传同时进行时也不会超出
+	if w.upload && !w.t.reserveClientUsage(w.ip, int64(len(p))) {
+		w.err = errDAVUploadLimit
+		return 0, w.err
+	}
+	n, err := w.dst.Write(p)
+	w.written += int64(n)
+	if w.upload {
+		w.reserved += int64(len(p))
+	}
+	if err != nil {
+		w.err = err
+	}
+	return n, err
+}
+
+func (w *davWriter) Close() error {
+	err := w.dst.Close()
+	if w.err == nil && err == nil {
+		err = replacePart(w.share, w.part, w.rel)
+	}
+	if w.err != nil || err != nil {
+		w.share.Remove(w.part)
+		w.t.releaseClientUsage(w.ip, w.reserved)
+		w.reserved = 0
+		return errors.Join(w.err, err)
+	}
+	if !w.upload {
+		return nil
+	}
+	// 预留多于实际写入的部分退回
+	w.t.releaseClientUsage(w.ip, w.reserved-w.written)
+	w.reserved = w.written
+	w.t.fileReceived(FileInfo{
+		Name:       w.rel,
+		Size:       w.written,
+		UploadedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}, w.device)
+	return nil
+}
+
+func (w *davWriter) Stat() (fs.FileInfo, error) {
+	return &entryInfo{name: path.Base(w.rel), size: w.written, modTime: time.Now()}, nil
+}
+
+func (w *davWriter) Read(p []byte) (int, error) {
+	return 0, davPathError("read", w.rel, fs.ErrInvalid)
+}
+
+func (w *davWriter) Seek(offset int64, whence int) (int64, error) {
+	return 0, davPathError("seek", w.rel, fs.ErrInvalid)
+}
+
+func (w *davWriter) Readdir(count int) ([]fs.FileInfo, error) {
+	return nil, davPathError("readdir", w.rel, fs.ErrInvalid)
+}
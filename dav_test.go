@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDAVUploadOnlyHidesFiles(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "docs"), 0755)
+	os.WriteFile(filepath.Join(dir, "docs", "secret.txt"), []byte("secret"), 0644)
+	s := NewAppServer(dir)
+	s.SetMode(ModeUploadOnly)
+	h := s.Handler()
+
+	do := func(method, target, depth, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		if depth != "" {
+			req.Header.Set("Depth", depth)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// 根目录可以连接，但列出为空
+	rec := do("PROPFIND", "/dav/", "1", "")
+	if rec.Code != http.StatusMultiStatus || strings.Contains(rec.Body.String(), "docs") {
+		t.Fatalf("root: %d %s", rec.Code, rec.Body.String())
+	}
+	for _, target := range []string{"/dav/docs", "/dav/docs/", "/dav/docs/secret.txt", "/dav/missing"} {
+		for _, depth := range []string{"0", "1"} {
+			rec := do("PROPFIND", target, depth, "")
+			if rec.Code != http.StatusForbidden || strings.Contains(rec.Body.String(), "secret") {
+				t.Errorf("PROPFIND %s depth %s: %d %s", target, depth, rec.Code, rec.Body.String())
+			}
+		}
+	}
+
+	// 仍然可以上传到子目录
+	if rec := do("PUT", "/dav/docs/new.txt", "", "hello"); rec.Code != http.StatusCreated {
+		t.Fatalf("PUT: %d %s", rec.Code, rec.Body.String())
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "docs", "new.txt")); string(data) != "hello" {
+		t.Fatalf("uploaded %q", data)
+	}
+
+	// 不能覆盖看不到的文件
+	if rec := do("PUT", "/dav/docs/secret.txt", "", "overwritten"); rec.Code != http.StatusForbidden {
+		t.Fatalf("PUT existing: %d %s", rec.Code, rec.Body.String())
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "docs", "secret.txt")); string(data) != "secret" {
+		t.Fatalf("existing file changed to %q", data)
+	}
+
+	// 可以读取时子目录正常列出
+	s.SetMode(ModeReadWrite)
+	if rec := do("PROPFIND", "/dav/docs/", "1", ""); rec.Code != http.StatusMultiStatus || !strings.Contains(rec.Body.String(), "secret.txt") {
+		t.Fatalf("read-write: %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestDAVFailedPutKeepsFile(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("old"), 0644)
+	s := NewAppServer(dir)
+	s.Limits.MaxFileSize = 10
+	h := s.Handler()
+
+	put := func(body string) int {
+		req := httptest.NewRequest("PUT", "/dav/a.txt", strings.NewReader(body))
+		req.ContentLength = -1 // 不声明大小，写入时才超出限制
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := put(strings.Repeat("x", 100)); code < 400 {
+		t.Fatalf("too large: %d", code)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "a.txt")); string(data) != "old" {
+		t.Fatalf("file changed to %q", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("%d files left", len(entries))
+	}
+
+	if code := put("new"); code != http.StatusCreated && code != http.StatusNoContent {
+		t.Fatalf("replace: %d", code)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "a.txt")); string(data) != "new" {
+		t.Fatalf("replaced content %q", data)
+	}
+}
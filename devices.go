@@ -0,0 +1,523 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 保存设备ID的 cookie 名称
+const deviceCookie = "kc_device"
+
+// 设备禁止方式
+type BlockMode int
+
+const (
+	BlockNone      BlockMode = iota
+	BlockSession             // 本次运行期间禁止
+	BlockPermanent           // 永久禁止，保存到配置
+)
+
+// 连接过的设备
+type Device struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	IP       string    `json:"ip"`
+	Agent    string    `json:"agent"`
+	LastSeen time.Time `json:"lastSeen"`
+	BytesIn  int64     `json:"bytesIn"`  // 设备上传的字节数
+	BytesOut int64     `json:"bytesOut"` // 设备下载的字节数
+	Active   int       `json:"active"`   // 正在进行的请求数
+	Blocked  BlockMode `json:"blocked"`
+}
+
+// 保存到配置中的设备信息
+type SavedDevice struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Blocked bool   `json:"blocked"`
+	IP      string `json:"ip,omitempty"` // 永久禁止的设备最后使用的IP，换用新 cookie 后仍被禁止
+}
+
+type deviceEntry struct {
+	Device
+	conns map[net.Conn]int // 正在使用的连接及其请求数
+}
+
+// 记录的不支持 cookie 的客户端数量上限，超出时去掉最久未访问的
+const maxAnonymousDevices = 1024
+
+// 不支持 cookie 的客户端分配的设备ID
+type anonymousDevice struct {
+	id       string
+	lastSeen time.Time
+}
+
+// 设备列表，记录每个浏览器的访问情况，跨多次共享保留
+type DeviceRegistry struct {
+	mu         sync.Mutex
+	devices    map[string]*deviceEntry
+	blockedIPs map[string]map[string]bool  // 禁止的设备使用过的IP及对应的设备，防止清除或伪造 cookie 后重新连接
+	anonymous  map[string]*anonymousDevice // 不支持 cookie 的客户端，键为IP和浏览器标识
+	key        []byte                      // 签名设备 cookie 的密钥，保存到配置中，重启后设备保持不变
+
+	OnSave func() // 设备名称或永久禁止列表变化时调用，用于保存配置
+}
+
+func NewDeviceRegistry() *DeviceRegistry {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return &DeviceRegistry{
+		devices:    make(map[string]*deviceEntry),
+		blockedIPs: make(map[string]map[string]bool),
+		anonymous:  make(map[string]*anonymousDevice),
+		key:        key,
+	}
+}
+
+// 签名 cookie 的密钥，十六进制
+func (d *DeviceRegistry) Key() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return hex.EncodeToString(d.key)
+}
+
+// 使用保存的密钥，格式不正确时保留新生成的密钥
+func (d *DeviceRegistry) SetKey(key string) {
+	b, err := hex.DecodeString(key)
+	if err != nil || len(b) < 32 {
+		return
+	}
+	d.mu.Lock()
+	d.key = b
+	d.mu.Unlock()
+}
+
+// 载入保存的设备
+func (d *DeviceRegistry) Load(saved []SavedDevice) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, s := range saved {
+		e := d.entry(s.ID)
+		e.Name = s.Name
+		if s.Blocked {
+			e.Blocked = BlockPermanent
+			e.IP = s.IP
+			d.blockIP(s.IP, s.ID)
+		}
+	}
+}
+
+// 需要保存的设备
+func (d *DeviceRegistry) Saved() []SavedDevice {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	saved := []SavedDevice{}
+	for _, e := range d.devices {
+		if e.Name == "" && e.Blocked != BlockPermanent {
+			continue
+		}
+		s := SavedDevice{ID: e.ID, Name: e.Name, Blocked: e.Blocked == BlockPermanent}
+		if s.Blocked {
+			s.IP = e.IP
+		}
+		saved = append(saved, s)
+	}
+	sort.Slice(saved, func(i, j int) bool { return saved[i].ID < saved[j].ID })
+	return saved
+}
+
+// 获取设备，不存在时创建，调用时需持有锁
+func (d *DeviceRegistry) entry(id string) *deviceEntry {
+	e, ok := d.devices[id]
+	if !ok {
+		e = &deviceEntry{Device: Device{ID: id}, conns: make(map[net.Conn]int)}
+		d.devices[id] = e
+	}
+	return e
+}
+
+// 所有访问过的设备，最近访问的在前
+func (d *DeviceRegistry) List() []Device {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	list := make([]Device, 0, len(d.devices))
+	for _, e := range d.devices {
+		if e.LastSeen.IsZero() {
+			continue // 仅在配置中保存，本次还未连接
+		}
+		list = append(list, e.Device)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].LastSeen.After(list[j].LastSeen) })
+	return list
+}
+
+// 获取设备信息
+func (d *DeviceRegistry) Get(id string) (Device, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e, ok := d.devices[id]
+	if !ok {
+		return Device{}, false
+	}
+	return e.Device, true
+}
+
+// 设备显示名称，未命名时根据浏览器标识生成
+func (dev Device) DisplayName() string {
+	if dev.Name != "" {
+		return dev.Name
+	}
+	suffix := dev.ID
+	if len(suffix) > 4 {
+		suffix = suffix[:4]
+	}
+	return agentPlatform(dev.Agent) + "-" + suffix
+}
+
+// 修改设备名称
+func (d *DeviceRegistry) Rename(id, name string) {
+	d.mu.Lock()
+	if e, ok := d.devices[id]; ok {
+		e.Name = strings.TrimSpace(name)
+	}
+	d.mu.Unlock()
+	d.save()
+}
+
+// 断开设备当前的所有连接
+func (d *DeviceRegistry) Kick(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if e, ok := d.devices[id]; ok {
+		for conn := range e.conns {
+			conn.Close()
+		}
+	}
+}
+
+// 禁止设备访问并断开连接
+func (d *DeviceRegistry) Block(id string, mode BlockMode) {
+	d.mu.Lock()
+	if e, ok := d.devices[id]; ok {
+		e.Blocked = mode
+		if mode != BlockNone {
+			d.blockIP(e.IP, id)
+		}
+	}
+	d.mu.Unlock()
+	d.Kick(id)
+	d.save()
+}
+
+// 解除禁止，设备使用过的IP也不再禁止
+func (d *DeviceRegistry) Unblock(id string) {
+	d.mu.Lock()
+	if e, ok := d.devices[id]; ok {
+		e.Blocked = BlockNone
+	}
+	for ip, ids := range d.blockedIPs {
+		delete(ids, id)
+		if len(ids) == 0 {
+			delete(d.blockedIPs, ip)
+		}
+	}
+	d.mu.Unlock()
+	d.save()
+}
+
+// 记录被禁止的设备使用过的IP，调用时需持有锁
+func (d *DeviceRegistry) blockIP(ip, id string) {
+	if ip == "" {
+		return
+	}
+	if d.blockedIPs[ip] == nil {
+		d.blockedIPs[ip] = make(map[string]bool)
+	}
+	d.blockedIPs[ip][id] = true
+}
+
+func (d *DeviceRegistry) save() {
+	if d.OnSave != nil {
+		d.OnSave()
+	}
+}
+
+// 记录一次访问，返回设备是否被禁止；passive 的请求（如事件推送）不算作正在传输
+func (d *DeviceRegistry) touch(id, ip, agent string, conn net.Conn, passive bool) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e := d.entry(id)
+	e.IP = ip
+	e.Agent = agent
+	e.LastSeen = time.Now()
+	if e.Blocked != BlockNone {
+		// 记住被禁止设备的新IP，换用新 cookie 后仍然无法连接
+		d.blockIP(ip, id)
+		return true
+	}
+	if len(d.blockedIPs[ip]) > 0 {
+		return true
+	}
+	if !passive {
+		e.Active++
+	}
+	if conn != nil {
+		e.conns[conn]++
+	}
+	return false
+}
+
+// 请求结束
+func (d *DeviceRegistry) release(id string, conn net.Conn, passive bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e := d.entry(id)
+	if !passive {
+		e.Active--
+	}
+	e.LastSeen = time.Now()
+	if conn != nil {
+		if e.conns[conn]--; e.conns[conn] <= 0 {
+			delete(e.conns, conn)
+		}
+	}
+}
+
+// 累计设备的传输字节数
+func (d *DeviceRegistry) addBytes(id string, in, out int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e := d.entry(id)
+	e.BytesIn += in
+	e.BytesOut += out
+}
+
+type deviceKey struct{}
+type connKey struct{}
+
+// 在请求的上下文中保存连接，用于断开设备
+func saveConnInContext(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, connKey{}, c)
+}
+
+// 获取请求所属的设备ID
+func deviceID(r *http.Request) string {
+	id, _ := r.Context().Value(deviceKey{}).(string)
+	return id
+}
+
+// 生成随机的设备ID
+func newDeviceID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// 设备 cookie 的值，由设备ID和签名组成，客户端无法伪造其他设备的 cookie
+func (d *DeviceRegistry) cookieValue(id string) string {
+	d.mu.Lock()
+	mac := hmac.New(sha256.New, d.key)
+	d.mu.Unlock()
+	mac.Write([]byte(id))
+	return id + "." + hex.EncodeToString(mac.Sum(nil))
+}
+
+// 从 cookie 中取出设备ID，签名不正确时返回空
+func (d *DeviceRegistry) cookieDevice(value string) string {
+	id, _, ok := strings.Cut(value, ".")
+	if !ok || len(id) != 32 || !hmac.Equal([]byte(value), []byte(d.cookieValue(id))) {
+		return ""
+	}
+	return id
+}
+
+// 没有有效 cookie 的请求按IP和浏览器标识分配设备ID，
+// 避免不支持 cookie 的客户端每次请求都被当作新设备
+func (d *DeviceRegistry) anonymousDevice(ip, agent string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	key := ip + "|" + agent
+	a, ok := d.anonymous[key]
+	if !ok {
+		if len(d.anonymous) >= maxAnonymousDevices {
+			oldest := ""
+			for k, v := range d.anonymous {
+				if oldest == "" || v.lastSeen.Before(d.anonymous[oldest].lastSeen) {
+					oldest = k
+				}
+			}
+			delete(d.anonymous, oldest)
+		}
+		a = &anonymousDevice{id: newDeviceID()}
+		d.anonymous[key] = a
+	}
+	a.lastSeen = time.Now()
+	return a.id
+}
+
+// 识别设备、统计流量并拦截被禁止的设备
+func (d *DeviceRegistry) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var id string
+		if c, err := r.Cookie(deviceCookie); err == nil {
+			id = d.cookieDevice(c.Value)
+		}
+		if id == "" {
+			id = d.anonymousDevice(clientIP(r), r.UserAgent())
+			http.SetCookie(w, &http.Cookie{
+				Name:     deviceCookie,
+				Value:    d.cookieValue(id),
+				Path:     "/",
+				MaxAge:   10 * 365 * 24 * 3600,
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+
+		conn, _ := r.Context().Value(connKey{}).(net.Conn)
+		passive := r.URL.Path == apiV1Prefix+"/events"
+		if d.touch(id, clientIP(r), r.UserAgent(), conn, passive) {
+			writeError(w, r, http.StatusForbidden, "device_blocked")
+			return
+		}
+		defer d.release(id, conn, passive)
+
+		r = r.WithContext(context.WithValue(r.Context(), deviceKey{}, id))
+		if r.Body != nil {
+			r.Body = &countingReader{r: r.Body, count: func(n int64) { d.addBytes(id, n, 0) }}
+		}
+		w = &countingWriter{ResponseWriter: w, count: func(n int64) { d.addBytes(id, 0, n) }}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// 获取或修改当前设备名称的处理函数
+func (t *AppServer) deviceHandler(w http.ResponseWriter, r *http.Request) {
+	id := deviceID(r)
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var body struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, r, http.StatusBadRequest, "bad_request")
+			return
+		}
+		if len([]rune(body.Name)) > 32 {
+			writeError(w, r, http.StatusBadRequest, "device_name_too_long")
+			return
+		}
+		t.Devices.Rename(id, body.Name)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed")
+		return
+	}
+
+	dev, _ := t.Devices.Get(id)
+	writeResult(w, r, http.StatusOK, "ok", map[string]any{
+		"id":   dev.ID,
+		"name": dev.DisplayName(),
+	})
+}
+
+// 根据浏览器标识判断设备平台
+func agentPlatform(agent string) string {
+	a := strings.ToLower(agent)
+	switch {
+	case strings.Contains(a, "iphone"):
+		return "iPhone"
+	case strings.Contains(a, "ipad"):
+		return "iPad"
+	case strings.Contains(a, "android"):
+		return "Android"
+	case strings.Contains(a, "windows"):
+		return "Windows"
+	case strings.Contains(a, "mac os"):
+		return "Mac"
+	case strings.Contains(a, "linux"):
+		return "Linux"
+	}
+	return "设备"
+}
+
+// 统计读取字节数的请求体
+type countingReader struct {
+	r     io.ReadCloser
+	count func(int64)
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if n > 0 {
+		c.count(int64(n))
+	}
+	return n, err
+}
+
+func (c *countingReader) Close() error {
+	return c.r.Close()
+}
+
+// 统计写入字节数的响应
+type countingWriter struct {
+	http.ResponseWriter
+	count func(int64)
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.ResponseWriter.Write(p)
+	if n > 0 {
+		c.count(int64(n))
+	}
+	return n, err
+}
+
+func (c *countingWriter) Flush() {
+	if f, ok := c.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (c *countingWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// 同时记录状态码的响应
+type statusWriter struct {
+	countingWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.countingWriter.Write(p)
+}
+
+// 响应的状态码，未写入时为 200
+func (w *statusWriter) code() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func newDeviceRequest(ip string, cookie *http.Cookie) *http.Request {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = ip + ":1234"
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	return r
+}
+
+func TestDeviceCookieSigned(t *testing.T) {
+	reg := NewDeviceRegistry()
+	var got string
+	h := reg.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = deviceID(r)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newDeviceRequest("10.0.0.1", nil))
+	cookie := rec.Result().Cookies()[0]
+	first := got
+	if len(first) != 32 {
+		t.Fatalf("id = %q", first)
+	}
+
+	// 签名正确的 cookie 保持同一设备
+	h.ServeHTTP(httptest.NewRecorder(), newDeviceRequest("10.0.0.9", cookie))
+	if got != first {
+		t.Fatalf("signed cookie: got %q, want %q", got, first)
+	}
+
+	// 只知道设备ID无法冒充
+	forged := &http.Cookie{Name: deviceCookie, Value: first}
+	h.ServeHTTP(httptest.NewRecorder(), newDeviceRequest("10.0.0.2", forged))
+	if got == first {
+		t.Fatal("unsigned cookie accepted")
+	}
+
+	// 另一个密钥签名的 cookie 无效
+	other := NewDeviceRegistry()
+	h.ServeHTTP(httptest.NewRecorder(), newDeviceRequest("10.0.0.2", &http.Cookie{Name: deviceCookie, Value: other.cookieValue(first)}))
+	if got == first {
+		t.Fatal("cookie signed with another key accepted")
+	}
+
+	// 保存的密钥在重启后仍然有效
+	restored := NewDeviceRegistry()
+	restored.SetKey(reg.Key())
+	if restored.cookieDevice(cookie.Value) != first {
+		t.Fatal("restored key rejects cookie")
+	}
+}
+
+func TestDeviceBlockFollowsIP(t *testing.T) {
+	reg := NewDeviceRegistry()
+	h := reg.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newDeviceRequest("10.0.0.1", nil))
+	id := reg.List()[0].ID
+	reg.Block(id, BlockPermanent)
+
+	// 清除 cookie 后仍被禁止
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, newDeviceRequest("10.0.0.1", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("new cookie from blocked IP: %d", rec.Code)
+	}
+
+	// 永久禁止保存IP，重启后换用新 cookie 也无法连接
+	saved := reg.Saved()
+	if len(saved) != 1 || saved[0].IP != "10.0.0.1" {
+		t.Fatalf("saved = %+v", saved)
+	}
+	restored := NewDeviceRegistry()
+	restored.Load(saved)
+	rec = httptest.NewRecorder()
+	restored.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(rec, newDeviceRequest("10.0.0.1", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("after restart: %d", rec.Code)
+	}
+}
+
+func TestApprovalBoundToIP(t *testing.T) {
+	s := NewAppServer(t.TempDir())
+	s.SetRequireApproval(true)
+	asked := 0
+	s.OnApprovalRequest = func(ctx context.Context, req UploadRequest, decide func(bool)) {
+		asked++
+		decide(true)
+	}
+
+	req := UploadRequest{DeviceID: "dev", IP: "10.0.0.1"}
+	if s.requestApproval(context.Background(), req) != approvalAllowed || asked != 1 {
+		t.Fatal("first request")
+	}
+	s.requestApproval(context.Background(), req)
+	if asked != 1 {
+		t.Fatal("approved device asked again")
+	}
+
+	// 同一设备ID来自其他IP时需要重新确认
+	req.IP = "10.0.0.2"
+	s.requestApproval(context.Background(), req)
+	if asked != 2 {
+		t.Fatal("approval reused from another IP")
+	}
+
+	s.ForgetApproval("dev")
+	req.IP = "10.0.0.1"
+	s.requestApproval(context.Background(), req)
+	if asked != 3 {
+		t.Fatal("forgotten approval still valid")
+	}
+}
+
+func TestDeviceUnblockClearsIPs(t *testing.T) {
+	reg := NewDeviceRegistry()
+	h := reg.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, newDeviceRequest("10.0.0.1", nil))
+	cookie := rec.Result().Cookies()[0]
+	id := reg.List()[0].ID
+	reg.Block(id, BlockSession)
+
+	// 被禁止的设备换了IP，新的IP也被禁止
+	h.ServeHTTP(httptest.NewRecorder(), newDeviceRequest("10.0.0.2", cookie))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, newDeviceRequest("10.0.0.2", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("other device on blocked IP: %d", rec.Code)
+	}
+
+	// 解除后使用过的IP都恢复访问
+	reg.Unblock(id)
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, newDeviceRequest(ip, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s after unblock: %d", ip, rec.Code)
+		}
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, newDeviceRequest("10.0.0.2", cookie))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unblocked device: %d", rec.Code)
+	}
+}
+
+func TestAnonymousDevicesCapped(t *testing.T) {
+	reg := NewDeviceRegistry()
+	first := reg.anonymousDevice("10.0.0.1", "curl")
+	for i := range maxAnonymousDevices + 10 {
+		reg.anonymousDevice("10.0.1.1", "agent-"+strconv.Itoa(i))
+	}
+	if n := len(reg.anonymous); n > maxAnonymousDevices {
+		t.Fatalf("%d anonymous devices kept", n)
+	}
+	// 最久未访问的被去掉，再次访问时分配新的ID
+	if reg.anonymousDevice("10.0.0.1", "curl") == first {
+		t.Fatal("oldest anonymous device kept")
+	}
+}
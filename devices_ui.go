@@ -0,0 +1,116 @@
+package main
+
+import (
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 已连接设备列表，可以改名、断开或禁止设备
+func createDevicesPanel(window fyne.Window, state *AppState) fyne.CanvasObject {
+	var devices []Device
+
+	list := widget.NewList(
+		func() int {
+			return len(devices)
+		},
+		func() fyne.CanvasObject {
+			name := widget.NewLabelWithStyle(tr("ui.device_name"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+			detail := widget.NewLabel(tr("ui.details"))
+			buttons := container.NewHBox(
+				widget.NewButton(tr("ui.rename"), nil),
+				widget.NewButton(tr("ui.disconnect"), nil),
+				widget.NewButton(tr("ui.block_session"), nil),
+				widget.NewButton(tr("ui.block_permanent"), nil),
+			)
+			return container.NewBorder(nil, nil, nil, buttons, container.NewVBox(name, detail))
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			dev := devices[i]
+			row := o.(*fyne.Container)
+			info := row.Objects[0].(*fyne.Container)
+			buttons := row.Objects[1].(*fyne.Container)
+
+			info.Objects[0].(*widget.Label).SetText(dev.DisplayName())
+			info.Objects[1].(*widget.Label).SetText(deviceDetail(dev))
+
+			renameBtn := buttons.Objects[0].(*widget.Button)
+			kickBtn := buttons.Objects[1].(*widget.Button)
+			sessionBtn := buttons.Objects[2].(*widget.Button)
+			permanentBtn := buttons.Objects[3].(*widget.Button)
+
+			renameBtn.OnTapped = func() {
+				entry := widget.NewEntry()
+				entry.SetText(dev.Name)
+				dialog.ShowForm(tr("ui.rename_device"), tr("ui.save"), tr("ui.cancel"), []*widget.FormItem{
+					widget.NewFormItem(tr("ui.name"), entry),
+				}, func(ok bool) {
+					if ok {
+						state.Devices.Rename(dev.ID, entry.Text)
+					}
+				}, window)
+			}
+			kickBtn.OnTapped = func() {
+				state.Devices.Kick(dev.ID)
+				if state.Server != nil {
+					state.Server.ForgetApproval(dev.ID)
+				}
+			}
+
+			if dev.Blocked == BlockNone {
+				sessionBtn.SetText(tr("ui.block_session"))
+				sessionBtn.OnTapped = func() {
+					state.Devices.Block(dev.ID, BlockSession)
+				}
+				permanentBtn.Show()
+				permanentBtn.OnTapped = func() {
+					dialog.ShowConfirm(tr("ui.block_permanent"), tr("ui.block_confirm", dev.DisplayName()), func(ok bool) {
+						if ok {
+							state.Devices.Block(dev.ID, BlockPermanent)
+						}
+					}, window)
+				}
+			} else {
+				sessionBtn.SetText(tr("ui.unblock"))
+				sessionBtn.OnTapped = func() {
+					state.Devices.Unblock(dev.ID)
+				}
+				permanentBtn.Hide()
+			}
+		},
+	)
+
+	refresh := func() {
+		devices = state.Devices.List()
+		list.Refresh()
+	}
+	refresh()
+
+	// 定时刷新在线状态和流量
+	go func() {
+		for range time.Tick(2 * time.Second) {
+			fyne.Do(refresh)
+		}
+	}()
+
+	return list
+}
+
+// 设备详情：IP、最近访问时间、流量和状态
+func deviceDetail(dev Device) string {
+	status := tr("ui.status_idle")
+	switch {
+	case dev.Blocked == BlockSession:
+		status = tr("ui.block_session")
+	case dev.Blocked == BlockPermanent:
+		status = tr("ui.block_permanent")
+	case dev.Active > 0:
+		status = tr("ui.status_transferring")
+	}
+	return tr("ui.device_detail",
+		dev.IP, status, dev.LastSeen.Format("15:04:05"),
+		formatFileSize(dev.BytesIn), formatFileSize(dev.BytesOut))
+}
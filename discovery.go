@@ -0,0 +1,162 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 局域网发现使用的组播地址
+const discoveryGroup = "239.255.77.77:8001"
+
+const (
+	announceInterval = 2 * time.Second  // 广播间隔
+	peerExpiry       = 10 * time.Second // 超过该时间未收到广播视为离线
+)
+
+// 局域网中正在共享的其他快传
+type Peer struct {
+	ID       string
+	Name     string
+	Addr     string // host:port
+	LastSeen time.Time
+}
+
+// 广播内容
+type beacon struct {
+	App  string `json:"app"`
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Port int    `json:"port"`
+}
+
+// 局域网发现，共享期间广播本机，同时收集其他快传
+type Discovery struct {
+	ID   string
+	Name string
+
+	port       atomic.Int64 // 广播的端口
+	announcing atomic.Bool
+	mu         sync.Mutex
+	peers      map[string]*Peer
+}
+
+func NewDiscovery(name string, port int) *Discovery {
+	b := make([]byte, 8)
+	rand.Read(b)
+	d := &Discovery{
+		ID:    hex.EncodeToString(b),
+		Name:  name,
+		peers: make(map[string]*Peer),
+	}
+	d.port.Store(int64(port))
+	return d
+}
+
+// 本机名称，用于在其他快传中显示
+func hostName() string {
+	name, err := os.Hostname()
+	if err != nil || name == "" {
+		return "快传"
+	}
+	return name
+}
+
+// 开始监听和广播
+func (d *Discovery) Start() {
+	group, err := net.ResolveUDPAddr("udp4", discoveryGroup)
+	if err != nil {
+		log.Printf("解析组播地址失败: %v", err)
+		return
+	}
+	go d.listen(group)
+	go d.announce(group)
+}
+
+// 修改广播的端口，更换端口后开始共享时调用
+func (d *Discovery) SetPort(port int) {
+	d.port.Store(int64(port))
+}
+
+// 开启或关闭广播，仅在共享时广播本机
+func (d *Discovery) SetAnnouncing(on bool) {
+	d.announcing.Store(on)
+}
+
+// 当前在线的其他快传，按名称排序
+func (d *Discovery) Peers() []Peer {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var peers []Peer
+	for id, p := range d.peers {
+		if time.Since(p.LastSeen) > peerExpiry {
+			delete(d.peers, id)
+			continue
+		}
+		peers = append(peers, *p)
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].Name < peers[j].Name })
+	return peers
+}
+
+func (d *Discovery) listen(group *net.UDPAddr) {
+	conn, err := net.ListenMulticastUDP("udp4", nil, group)
+	if err != nil {
+		log.Printf("监听局域网发现失败: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	buf := make([]byte, 1024)
+	for {
+		n, src, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			log.Printf("接收局域网发现失败: %v", err)
+			return
+		}
+		var b beacon
+		if err := json.Unmarshal(buf[:n], &b); err != nil || b.App != "kuaichuan" || b.ID == d.ID {
+			continue
+		}
+
+		d.mu.Lock()
+		d.peers[b.ID] = &Peer{
+			ID:       b.ID,
+			Name:     b.Name,
+			Addr:     net.JoinHostPort(src.IP.String(), strconv.Itoa(b.Port)),
+			LastSeen: time.Now(),
+		}
+		d.mu.Unlock()
+	}
+}
+
+func (d *Discovery) announce(group *net.UDPAddr) {
+	var conn *net.UDPConn
+	for range time.Tick(announceInterval) {
+		if !d.announcing.Load() {
+			continue
+		}
+		data, _ := json.Marshal(beacon{App: "kuaichuan", ID: d.ID, Name: d.Name, Port: int(d.port.Load())})
+		if conn == nil {
+			c, err := net.DialUDP("udp4", nil, group)
+			if err != nil {
+				log.Printf("局域网广播失败: %v", err)
+				continue
+			}
+			conn = c
+		}
+		if _, err := conn.Write(data); err != nil {
+			// 网络变化后重新连接
+			conn.Close()
+			conn = nil
+		}
+	}
+}
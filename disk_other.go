@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+import "errors"
+
+// 当前平台无法获取磁盘可用空间
+func diskFree(dir string) (int64, error) {
+	return 0, errors.New("不支持获取磁盘空间")
+}
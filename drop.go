@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 拖入窗口的文件和文件夹组成的只读存储，根目录下是拖入的各项，不需要先移动到共享文件夹
+type DropStorage struct {
+	names []string          // 根目录下的名称，按拖入的顺序
+	roots map[string]string // 名称对应的本地路径
+
+	// 是否跟随拖入的文件夹内的符号链接
+	FollowSymlinks bool
+}
+
+func NewDropStorage(paths []string, followSymlinks bool) *DropStorage {
+	s := &DropStorage{roots: make(map[string]string), FollowSymlinks: followSymlinks}
+	names := dropNames(paths)
+	for i, p := range paths {
+		s.names = append(s.names, names[i])
+		s.roots[names[i]] = p
+	}
+	return s
+}
+
+// 拖入的各项在根目录下的名称，同名时在后面加上序号
+func dropNames(paths []string) []string {
+	names := make([]string, len(paths))
+	used := make(map[string]bool)
+	for i, p := range paths {
+		base := filepath.Base(p)
+		name := base
+		ext := path.Ext(base)
+		for n := 1; used[name]; n++ {
+			name = fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(base, ext), n, ext)
+		}
+		used[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+// 拆分为拖入的项和其中的相对路径
+func (s *DropStorage) resolve(rel string) (string, string, error) {
+	rel = cleanRelPath(rel)
+	name, sub, _ := strings.Cut(rel, "/")
+	root, ok := s.roots[name]
+	if !ok {
+		return "", "", &fs.PathError{Op: "open", Path: rel, Err: fs.ErrNotExist}
+	}
+	return root, sub, nil
+}
+
+// 在拖入的文件夹中操作，访问限制在该文件夹内
+func (s *DropStorage) inDir(root string, fn func(share *ShareFS) error) error {
+	share, err := OpenShareFS(root, s.FollowSymlinks)
+	if err != nil {
+		return err
+	}
+	defer share.Close()
+	return fn(share)
+}
+
+func (s *DropStorage) List(rel string) ([]fs.FileInfo, error) {
+	if cleanRelPath(rel) == "" {
+		infos := make([]fs.FileInfo, 0, len(s.names))
+		for _, name := range s.names {
+			if info, err := s.Stat(name); err == nil {
+				infos = append(infos, info)
+			}
+		}
+		return infos, nil
+	}
+	root, sub, err := s.resolve(rel)
+	if err != nil {
+		return nil, err
+	}
+	var infos []fs.FileInfo
+	err = s.inDir(root, func(share *ShareFS) error {
+		infos, err = share.List(sub)
+		return err
+	})
+	return infos, err
+}
+
+func (s *DropStorage) Stat(rel string) (fs.FileInfo, error) {
+	if cleanRelPath(rel) == "" {
+		return &entryInfo{name: ".", modTime: time.Now(), dir: true}, nil
+	}
+	root, sub, err := s.resolve(rel)
+	if err != nil {
+		return nil, err
+	}
+	if sub == "" {
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, err
+		}
+		return &entryInfo{name: path.Base(cleanRelPath(rel)), size: info.Size(), modTime: info.ModTime(), dir: info.IsDir()}, nil
+	}
+	var info fs.FileInfo
+	err = s.inDir(root, func(share *ShareFS) error {
+		info, err = share.Stat(sub)
+		return err
+	})
+	return info, err
+}
+
+// 打开的文件在关闭文件夹后仍然可用
+func (s *DropStorage) Open(rel string) (StorageFile, error) {
+	root, sub, err := s.resolve(rel)
+	if err != nil {
+		return nil, err
+	}
+	if sub == "" {
+		return os.Open(root)
+	}
+	var f StorageFile
+	err = s.inDir(root, func(share *ShareFS) error {
+		f, err = share.Open(sub)
+		return err
+	})
+	return f, err
+}
+
+func (s *DropStorage) Walk(rel string, fn WalkFunc) error {
+	return walkList(s, rel, fn)
+}
+
+func (s *DropStorage) Create(rel string) (io.WriteCloser, error) {
+	return nil, &fs.PathError{Op: "create", Path: rel, Err: fs.ErrPermission}
+}
+
+func (s *DropStorage) Rename(from, to string) error {
+	return &fs.PathError{Op: "rename", Path: from, Err: fs.ErrPermission}
+}
+
+func (s *DropStorage) Remove(rel string) error {
+	return &fs.PathError{Op: "remove", Path: rel, Err: fs.ErrPermission}
+}
+
+func (s *DropStorage) MkdirAll(rel string) error {
+	return &fs.PathError{Op: "mkdir", Path: rel, Err: fs.ErrPermission}
+}
+
+func (s *DropStorage) Close() error {
+	return nil
+}
+
+// 新建临时分享拖入的文件，只有一项时直接分享该文件或文件夹
+func newTempLink(paths []string, followSymlinks bool) (ShareLink, error) {
+	link := ShareLink{Kind: LinkTemp, Files: paths, IsDir: true}
+	if len(paths) == 1 {
+		info, err := NewDropStorage(paths, followSymlinks).Stat(dropNames(paths)[0])
+		if err != nil {
+			return link, err
+		}
+		link.Path = info.Name()
+		link.IsDir = info.IsDir()
+	}
+	return link, nil
+}
+
+// 把拖入的文件和文件夹复制到共享文件夹的 dir 中，已存在的同名项加上序号。
+// progress 在每复制一部分后调用，返回复制后的名称
+func copyIntoShare(share Storage, dir string, paths []string, progress func(copied, total int64)) ([]string, error) {
+	files, err := collectSendFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	var total, copied int64
+	for _, f := range files {
+		total += f.Size
+	}
+	copyFile := func(src, dst string) error {
+		n, err := copyFileToStorage(share, src, dst, func(n int64) {
+			if progress != nil {
+				progress(copied+n, total)
+			}
+		})
+		copied += n
+		if err != nil {
+			return fmt.Errorf("%s: %w", filepath.Base(src), err)
+		}
+		return nil
+	}
+
+	names := make([]string, 0, len(paths))
+	for _, p := range paths {
+		name := uniqueFilePath(share, path.Join(dir, filepath.Base(p)))
+		names = append(names, name)
+		info, err := os.Stat(p)
+		if err != nil {
+			return names, err
+		}
+		if !info.IsDir() {
+			if err := copyFile(p, name); err != nil {
+				return names, err
+			}
+			continue
+		}
+		// 逐级创建文件夹，空文件夹也保留
+		err = filepath.WalkDir(p, func(fp string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(p, fp)
+			if err != nil {
+				return err
+			}
+			dst := path.Join(name, filepath.ToSlash(rel))
+			switch {
+			case d.IsDir():
+				return share.MkdirAll(dst)
+			case d.Type().IsRegular():
+				return copyFile(fp, dst)
+			}
+			return nil
+		})
+		if err != nil {
+			return names, err
+		}
+	}
+	return names, nil
+}
+
+// 复制一个本地文件到存储中
+func copyFileToStorage(share Storage, src, dst string, progress func(n int64)) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	out, err := share.Create(dst)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(out, &progressReader{r: in, report: progress})
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		share.Remove(dst)
+	}
+	return n, err
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 拖入窗口的文件和文件夹可以复制到共享文件夹，或直接作为临时分享
+func setupDrop(window fyne.Window, state *AppState) {
+	window.SetOnDropped(func(_ fyne.Position, uris []fyne.URI) {
+		var paths []string
+		for _, u := range uris {
+			if u.Scheme() == "file" {
+				paths = append(paths, u.Path())
+			}
+		}
+		if len(paths) > 0 {
+			showDropDialog(window, state, paths)
+		}
+	})
+}
+
+// 选择如何提供拖入的文件
+func showDropDialog(window fyne.Window, state *AppState, paths []string) {
+	names := make([]string, len(paths))
+	for i, p := range paths {
+		names[i] = filepath.Base(p)
+	}
+	message := widget.NewLabel(tr("ui.drop_message", len(paths), strings.Join(names, ", ")))
+	message.Wrapping = fyne.TextWrapWord
+
+	var d *dialog.CustomDialog
+	copyBtn := widget.NewButton(tr("ui.drop_copy"), func() {
+		d.Hide()
+		startCopyIntoShare(window, state, paths)
+	})
+	tempBtn := widget.NewButton(tr("ui.drop_temp"), func() {
+		d.Hide()
+		link, err := newTempLink(paths, state.FollowSymlinks)
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		showLinkQR(window, state, state.Links.Create(link, ""))
+	})
+	tempBtn.Importance = widget.HighImportance
+	if uploadDir, _ := state.UploadDir.Get(); uploadDir == "" {
+		copyBtn.Disable()
+	}
+	buttons := container.NewHBox(copyBtn, tempBtn, widget.NewButton(tr("ui.cancel"), func() { d.Hide() }))
+	content := container.NewVBox(message, widget.NewLabel(tr("ui.drop_hint")), container.NewCenter(buttons))
+	d = dialog.NewCustomWithoutButtons(tr("ui.drop_title"), content, window)
+	d.Resize(fyne.NewSize(480, 0))
+	d.Show()
+}
+
+// 复制到共享文件夹的根目录并显示进度，共享中时连接的设备刷新后即可看到
+func startCopyIntoShare(window fyne.Window, state *AppState, paths []string) {
+	uploadDir, _ := state.UploadDir.Get()
+	share, err := state.Storage.Open(uploadDir, state.FollowSymlinks)
+	if err != nil {
+		dialog.ShowError(fmt.Errorf("%s: %w", tr("ui.open_storage_failed"), err), window)
+		return
+	}
+
+	bar := widget.NewProgressBar()
+	d := dialog.NewCustomWithoutButtons(tr("ui.drop_copying"), bar, window)
+	d.Resize(fyne.NewSize(400, 0))
+	d.Show()
+
+	go func() {
+		defer share.Close()
+		var lastUpdate time.Time
+		names, err := copyIntoShare(share, "", paths, func(copied, total int64) {
+			// 限制界面刷新频率
+			if time.Since(lastUpdate) < 100*time.Millisecond && copied < total {
+				return
+			}
+			lastUpdate = time.Now()
+			fyne.Do(func() {
+				if total > 0 {
+					bar.SetValue(float64(copied) / float64(total))
+				}
+			})
+		})
+		fyne.Do(func() {
+			d.Hide()
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("%s: %w", tr("ui.drop_copy_failed"), err), window)
+				return
+			}
+			if state.OnFilesChanged != nil {
+				state.OnFilesChanged()
+			}
+			showToast(tr("ui.drop_copied", strings.Join(names, ", ")), window)
+		})
+	}()
+}
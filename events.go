@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SSE 连接的心跳间隔，避免空闲连接被代理或浏览器断开
+const eventHeartbeat = 25 * time.Second
+
+// 推送给浏览器的事件
+type ServerEvent struct {
+	Type string         `json:"type"` // 如 shutdown
+	Data map[string]any `json:"data,omitempty"`
+}
+
+// 事件广播，每个浏览器连接一个带缓冲的通道，来不及接收的事件直接丢弃
+type eventHub struct {
+	mu      sync.Mutex
+	clients map[chan ServerEvent]struct{}
+	closed  bool
+}
+
+// 订阅事件，返回的函数用于取消订阅；已关闭时返回 nil
+func (h *eventHub) subscribe() (chan ServerEvent, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, nil
+	}
+	if h.clients == nil {
+		h.clients = make(map[chan ServerEvent]struct{})
+	}
+	ch := make(chan ServerEvent, 16)
+	h.clients[ch] = struct{}{}
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.clients[ch]; ok {
+			delete(h.clients, ch)
+			close(ch)
+		}
+	}
+}
+
+// 向所有浏览器发送事件
+func (h *eventHub) Publish(e ServerEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.clients {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// 关闭所有连接，已发送的事件仍会先推送出去
+func (h *eventHub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for ch := range h.clients {
+		close(ch)
+	}
+	h.clients = nil
+}
+
+// 以 Server-Sent Events 推送服务器事件
+func (t *AppServer) eventsHandler(w http.ResponseWriter, r *http.Request) {
+	events, cancel := t.events.subscribe()
+	if events == nil {
+		writeError(w, r, http.StatusServiceUnavailable, "server_stopping")
+		return
+	}
+	defer cancel()
+
+	rc := http.NewResponseController(w)
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	rc.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			data, _ := json.Marshal(e)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+			rc.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			rc.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
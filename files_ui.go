@@ -0,0 +1,349 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 缩略图的边长
+const thumbnailSize = 40
+
+// 同时生成缩略图的数量
+var thumbnailSlots = make(chan struct{}, 2)
+
+// 一张缩略图，文件修改后重新生成
+type thumbnail struct {
+	modTime time.Time
+	res     fyne.Resource // 生成中或无法生成时为空
+}
+
+// 共享文件夹浏览器，显示文件的大小、日期、缩略图和上传来源，收到上传时自动刷新
+func createFilesPanel(window fyne.Window, state *AppState) fyne.CanvasObject {
+	var (
+		share      Storage
+		shareKey   string // 打开 share 时的共享文件夹和设置，变化后重新打开
+		shareDir   string
+		dir        string // 当前目录，相对共享文件夹
+		entries    []fs.FileInfo
+		sources    map[string]FileInfo // 文件最近一次的上传记录
+		thumbs     = make(map[string]*thumbnail)
+		loading    bool // 正在读取目录，期间的刷新请求合并为一次
+		reloadNext bool
+		reload     func()
+	)
+
+	local := func() bool {
+		return state.Storage.Type == "" || state.Storage.Type == "local"
+	}
+	localPath := func(rel string) string {
+		return filepath.Join(shareDir, filepath.FromSlash(rel))
+	}
+
+	// 打开共享文件夹的存储，更换文件夹后重新打开
+	openShare := func() (Storage, error) {
+		uploadDir, _ := state.UploadDir.Get()
+		key := fmt.Sprint(uploadDir, state.FollowSymlinks, state.Storage)
+		if share != nil && key == shareKey {
+			return share, nil
+		}
+		if share != nil {
+			share.Close()
+			share = nil
+		}
+		clear(thumbs)
+		if uploadDir == "" {
+			return nil, errors.New(tr("ui.folder_required"))
+		}
+		s, err := state.Storage.Open(uploadDir, state.FollowSymlinks)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", tr("ui.open_storage_failed"), err)
+		}
+		share, shareKey, shareDir = s, key, uploadDir
+		return share, nil
+	}
+
+	pathLabel := widget.NewLabel("/")
+	pathLabel.Truncation = fyne.TextTruncateEllipsis
+	empty := widget.NewLabel("")
+	empty.Hide()
+
+	// 在后台生成缩略图，完成后刷新列表
+	var list *widget.List
+	thumbFor := func(rel string, info fs.FileInfo) fyne.Resource {
+		if info.IsDir() || !isImageFile(rel) || info.Size() > thumbnailMaxSize {
+			return nil
+		}
+		if t, ok := thumbs[rel]; ok && t.modTime.Equal(info.ModTime()) {
+			return t.res
+		}
+		thumbs[rel] = &thumbnail{modTime: info.ModTime()}
+		s := share
+		go func() {
+			thumbnailSlots <- struct{}{}
+			defer func() { <-thumbnailSlots }()
+			f, err := s.Open(rel)
+			if err != nil {
+				return
+			}
+			data, err := makeThumbnail(f, thumbnailSize*2)
+			f.Close()
+			if err != nil {
+				return
+			}
+			fyne.Do(func() {
+				if t, ok := thumbs[rel]; ok && t.modTime.Equal(info.ModTime()) {
+					t.res = fyne.NewStaticResource(path.Base(rel)+".png", data)
+					list.Refresh()
+				}
+			})
+		}()
+		return nil
+	}
+
+	// 文件的大小、修改时间和上传来源
+	detail := func(rel string, info fs.FileInfo) string {
+		date := info.ModTime().Format("2006-01-02 15:04")
+		if info.IsDir() {
+			return date
+		}
+		parts := []string{formatFileSize(info.Size()), date}
+		if f, ok := sources[rel]; ok {
+			switch {
+			case f.Uploader != "":
+				parts = append(parts, tr("ui.files_from_link", f.Uploader))
+			case f.Device != "":
+				parts = append(parts, tr("ui.files_from", f.Device))
+			default:
+				parts = append(parts, tr("ui.files_uploaded"))
+			}
+		}
+		return strings.Join(parts, " · ")
+	}
+
+	open := func(rel string, info fs.FileInfo) {
+		if info.IsDir() {
+			dir = rel
+			reload()
+			return
+		}
+		if local() {
+			// 打开文件夹的命令同样会用默认程序打开文件
+			openFolder(localPath(rel))
+		}
+	}
+
+	rename := func(rel string) {
+		entry := widget.NewEntry()
+		entry.SetText(path.Base(rel))
+		entry.Validator = func(s string) error {
+			if !validFileName(strings.TrimSpace(s)) {
+				return errors.New(tr("ui.files_name_invalid"))
+			}
+			return nil
+		}
+		dialog.ShowForm(tr("ui.files_rename"), tr("ui.files_rename"), tr("ui.cancel"),
+			[]*widget.FormItem{widget.NewFormItem(tr("ui.files_new_name"), entry)}, func(ok bool) {
+				to := path.Join(dir, strings.TrimSpace(entry.Text))
+				if !ok || to == rel {
+					return
+				}
+				if _, err := share.Stat(to); err == nil {
+					dialog.ShowError(errors.New(tr("ui.files_exists", path.Base(to))), window)
+					return
+				}
+				if err := share.Rename(rel, to); err != nil {
+					dialog.ShowError(err, window)
+					return
+				}
+				// 来源记录跟随文件，失败时不影响重命名
+				renameInHistory(share, rel, to)
+				reload()
+			}, window)
+	}
+
+	remove := func(rel string) {
+		dialog.ShowConfirm(tr("ui.files_delete"), tr("ui.files_delete_confirm", path.Base(rel)), func(ok bool) {
+			if !ok {
+				return
+			}
+			if err := removeTree(share, rel, nil); err != nil {
+				dialog.ShowError(err, window)
+			}
+			reload()
+		}, window)
+	}
+
+	menu := func(rel string, info fs.FileInfo) *fyne.Menu {
+		openItem := fyne.NewMenuItem(tr("ui.files_open"), func() { open(rel, info) })
+		openItem.Disabled = !info.IsDir() && !local()
+		revealItem := fyne.NewMenuItem(tr("ui.files_reveal"), func() { revealFile(localPath(rel)) })
+		revealItem.Disabled = !local()
+		return fyne.NewMenu("",
+			openItem,
+			revealItem,
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem(tr("ui.files_rename"), func() { rename(rel) }),
+			fyne.NewMenuItem(tr("ui.files_delete"), func() { remove(rel) }),
+			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem(tr("ui.files_share_link"), func() { showNewLinkDialog(window, state, rel, info.IsDir()) }),
+		)
+	}
+
+	list = widget.NewList(
+		func() int {
+			return len(entries)
+		},
+		func() fyne.CanvasObject {
+			icon := widget.NewIcon(theme.FileIcon())
+			img := canvas.NewImageFromResource(nil)
+			img.FillMode = canvas.ImageFillContain
+			thumb := container.NewGridWrap(fyne.NewSquareSize(thumbnailSize), container.NewStack(icon, img))
+			name := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+			name.Truncation = fyne.TextTruncateEllipsis
+			info := widget.NewLabel("")
+			info.Truncation = fyne.TextTruncateEllipsis
+			more := widget.NewButtonWithIcon("", theme.MoreHorizontalIcon(), nil)
+			more.Importance = widget.LowImportance
+			return container.NewBorder(nil, nil, thumb, more, container.NewVBox(name, info))
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			e := entries[i]
+			rel := path.Join(dir, e.Name())
+			row := o.(*fyne.Container)
+			text := row.Objects[0].(*fyne.Container)
+			stack := row.Objects[1].(*fyne.Container).Objects[0].(*fyne.Container)
+			more := row.Objects[2].(*widget.Button)
+
+			text.Objects[0].(*widget.Label).SetText(e.Name())
+			text.Objects[1].(*widget.Label).SetText(detail(rel, e))
+
+			icon := stack.Objects[0].(*widget.Icon)
+			img := stack.Objects[1].(*canvas.Image)
+			switch res := thumbFor(rel, e); {
+			case res != nil:
+				img.Resource = res
+				img.Show()
+				img.Refresh()
+				icon.Hide()
+			case e.IsDir():
+				icon.SetResource(theme.FolderIcon())
+				icon.Show()
+				img.Hide()
+			case isImageFile(rel):
+				icon.SetResource(theme.FileImageIcon())
+				icon.Show()
+				img.Hide()
+			default:
+				icon.SetResource(theme.FileIcon())
+				icon.Show()
+				img.Hide()
+			}
+
+			more.OnTapped = func() {
+				pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(more)
+				widget.ShowPopUpMenuAtPosition(menu(rel, e), window.Canvas(), pos.AddXY(0, more.Size().Height))
+			}
+		},
+	)
+	list.OnSelected = func(i widget.ListItemID) {
+		list.UnselectAll()
+		if i < len(entries) {
+			open(path.Join(dir, entries[i].Name()), entries[i])
+		}
+	}
+
+	// 在后台读取当前目录和上传历史，文件夹在前，新文件在前
+	reload = func() {
+		if loading {
+			reloadNext = true
+			return
+		}
+		s, err := openShare()
+		if err != nil {
+			entries, sources, dir = nil, nil, ""
+			pathLabel.SetText("/")
+			empty.SetText(err.Error())
+			empty.Show()
+			list.Refresh()
+			return
+		}
+		loading = true
+		current := dir
+		go func() {
+			infos, listErr := s.List(current)
+			history, _ := readHistoryFile(s)
+			infos = slices.DeleteFunc(infos, func(info fs.FileInfo) bool {
+				return isInternalFile(path.Join(current, info.Name()), false)
+			})
+			slices.SortFunc(infos, func(a, b fs.FileInfo) int {
+				if a.IsDir() != b.IsDir() {
+					if a.IsDir() {
+						return -1
+					}
+					return 1
+				}
+				return b.ModTime().Compare(a.ModTime())
+			})
+			latest := make(map[string]FileInfo)
+			for _, f := range history {
+				if _, ok := latest[f.Name]; !ok {
+					latest[f.Name] = f
+				}
+			}
+
+			fyne.Do(func() {
+				loading = false
+				if listErr != nil && current != "" {
+					// 目录已被删除或重命名时回到上一级
+					dir = parentDir(current)
+					reload()
+					return
+				}
+				entries, sources = infos, latest
+				pathLabel.SetText("/" + current)
+				empty.SetText(tr("ui.files_empty"))
+				if listErr != nil {
+					empty.SetText(listErr.Error())
+				}
+				empty.Hidden = len(entries) > 0
+				list.Refresh()
+				if reloadNext {
+					reloadNext = false
+					reload()
+				}
+			})
+		}()
+	}
+
+	upBtn := widget.NewButtonWithIcon("", theme.NavigateBackIcon(), func() {
+		if dir != "" {
+			dir = parentDir(dir)
+			reload()
+		}
+	})
+	refreshBtn := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), func() { reload() })
+
+	// 收到上传或复制文件到共享文件夹后刷新，更换共享文件夹后回到根目录
+	state.OnFilesChanged = func() { reload() }
+	state.UploadDir.AddListener(binding.NewDataListener(func() {
+		dir = ""
+		reload()
+	}))
+
+	top := container.NewBorder(nil, nil, upBtn, refreshBtn, pathLabel)
+	return container.NewBorder(top, nil, nil, nil, container.NewStack(list, container.NewCenter(empty)))
+}
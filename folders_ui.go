@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"slices"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 最近和收藏的文件夹，点击后弹出菜单切换共享文件夹
+func newFoldersButton(window fyne.Window, state *AppState, switchTo func(dir string)) *widget.Button {
+	var btn *widget.Button
+	btn = widget.NewButtonWithIcon(tr("ui.folders"), theme.HistoryIcon(), func() {
+		menu := foldersMenu(window, state, switchTo)
+		pos := fyne.CurrentApp().Driver().AbsolutePositionForObject(btn)
+		widget.ShowPopUpMenuAtPosition(menu, window.Canvas(), pos.AddXY(0, btn.Size().Height))
+	})
+	return btn
+}
+
+func foldersMenu(window fyne.Window, state *AppState, switchTo func(dir string)) *fyne.Menu {
+	current, _ := state.UploadDir.Get()
+	item := func(label, dir string) *fyne.MenuItem {
+		it := fyne.NewMenuItem(label, func() {
+			// 本地文件夹被删除或移动后不能切换
+			if state.Storage.Type == "" || state.Storage.Type == "local" {
+				if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+					showToast(tr("ui.folder_missing", dir), window)
+					return
+				}
+			}
+			switchTo(dir)
+		})
+		it.Checked = dir == current
+		return it
+	}
+
+	var items []*fyne.MenuItem
+	for _, dir := range state.FavoriteFolders {
+		items = append(items, item("★ "+dir, dir))
+	}
+	if len(items) > 0 {
+		items = append(items, fyne.NewMenuItemSeparator())
+	}
+	recent := 0
+	for _, dir := range state.RecentFolders {
+		if !slices.Contains(state.FavoriteFolders, dir) {
+			items = append(items, item(dir, dir))
+			recent++
+		}
+	}
+	if recent > 0 {
+		items = append(items, fyne.NewMenuItemSeparator())
+	}
+
+	// 收藏或取消收藏当前文件夹
+	if current != "" {
+		if slices.Contains(state.FavoriteFolders, current) {
+			items = append(items, fyne.NewMenuItem(tr("ui.folder_unfavorite"), func() {
+				state.FavoriteFolders = slices.DeleteFunc(slices.Clone(state.FavoriteFolders), func(d string) bool { return d == current })
+				saveConfig(state)
+			}))
+		} else {
+			items = append(items, fyne.NewMenuItem(tr("ui.folder_favorite"), func() {
+				state.FavoriteFolders = append(slices.Clone(state.FavoriteFolders), current)
+				saveConfig(state)
+			}))
+		}
+	}
+	if recent > 0 {
+		items = append(items, fyne.NewMenuItem(tr("ui.folder_clear_recent"), func() {
+			state.RecentFolders = nil
+			saveConfig(state)
+		}))
+	}
+	if len(items) == 0 {
+		empty := fyne.NewMenuItem(tr("ui.folder_none"), nil)
+		empty.Disabled = true
+		items = append(items, empty)
+	}
+	return fyne.NewMenu("", items...)
+}
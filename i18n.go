@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// 界面语言
+type Lang string
+
+const (
+	LangZH Lang = "zh"
+	LangEN Lang = "en"
+)
+
+// 默认语言，客户端未指定或指定的语言不支持时使用
+const defaultLang = LangZH
+
+var supportedLangs = []Lang{LangZH, LangEN}
+
+// 各语言的文本，键为消息ID，API 错误的ID同时作为返回给客户端的错误码
+var catalogs = map[Lang]map[string]string{
+	LangZH: messagesZH,
+	LangEN: messagesEN,
+}
+
+// 按语言查找文本，找不到时依次使用默认语言和消息ID本身，有参数时按 fmt 格式化
+func T(lang Lang, id string, args ...any) string {
+	msg, ok := catalogs[lang][id]
+	if !ok {
+		msg, ok = catalogs[defaultLang][id]
+	}
+	if !ok {
+		msg = id
+	}
+	if len(args) > 0 {
+		msg = fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// 解析语言标签，如 zh-CN、en_US、zh-Hans，只看主语言
+func parseLang(tag string) (Lang, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	base, _, _ := strings.Cut(strings.ReplaceAll(tag, "_", "-"), "-")
+	for _, l := range supportedLangs {
+		if Lang(base) == l {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// 根据 Accept-Language 选择权重最高的已支持语言
+func requestLang(r *http.Request) Lang {
+	best, bestQ := defaultLang, 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if l, ok := parseLang(tag); ok && q > bestQ {
+			best, bestQ = l, q
+		}
+	}
+	return best
+}
+
+// 桌面端使用的语言，启动时根据设置或系统语言确定
+var uiLang = defaultLang
+
+// 桌面端文本
+func tr(id string, args ...any) string {
+	return T(uiLang, id, args...)
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"path"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 共享文件夹中的忽略规则文件，语法与 .gitignore 相同
+const ignoreFileName = ".kuaichuanignore"
+
+// 内置的忽略规则，可以在 .kuaichuanignore 中用 ! 重新显示
+var defaultIgnorePatterns = []string{
+	".*",
+	".DS_Store",
+	"Thumbs.db",
+	"desktop.ini",
+	"~$*",
+	"$RECYCLE.BIN/",
+	"System Volume Information/",
+}
+
+// Windows 和 macOS 默认的文件系统不区分大小写，HISTORY.JSON 与 history.json 是同一个文件
+var caseInsensitiveFS = runtime.GOOS == "windows" || runtime.GOOS == "darwin"
+
+// 快传自身使用的文件，始终隐藏，名称均为小写
+var internalFiles = map[string]bool{
+	historyFileName: true,
+	ignoreFileName:  true,
+}
+
+// 正在接收的上传先写入以此结尾的临时文件，完成后改为目标文件名
+const uploadPartSuffix = ".kuaichuan-part"
+
+// 上传 rel 时使用的临时文件，与目标文件位于同一目录
+func uploadPartName(rel string) string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return path.Join(path.Dir(rel), "."+path.Base(rel)+"."+hex.EncodeToString(b)+uploadPartSuffix)
+}
+
+// 是否为共享内部使用的文件，不显示也不能访问
+func isInternalFile(rel string, foldCase bool) bool {
+	if foldCase {
+		rel = strings.ToLower(rel)
+	}
+	return internalFiles[rel] || strings.HasSuffix(rel, uploadPartSuffix)
+}
+
+// 一条忽略规则
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// 忽略规则集合，后面的规则优先
+type IgnoreRules struct {
+	rules    []ignoreRule
+	foldCase bool // 匹配时不区分大小写
+}
+
+// 解析 gitignore 语法的规则，foldCase 用于不区分大小写的文件系统
+func ParseIgnoreRules(lines []string, foldCase bool) *IgnoreRules {
+	ig := &IgnoreRules{foldCase: foldCase}
+	for _, line := range lines {
+		if rule, ok := compileIgnoreRule(line, foldCase); ok {
+			ig.rules = append(ig.rules, rule)
+		}
+	}
+	return ig
+}
+
+func compileIgnoreRule(line string, foldCase bool) (ignoreRule, bool) {
+	line = strings.TrimRight(line, "\r")
+	// 去掉未转义的行尾空格
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	var rule ignoreRule
+	if strings.HasPrefix(line, "!") {
+		rule.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, "\\!") || strings.HasPrefix(line, "\\#") {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		rule.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return ignoreRule{}, false
+	}
+
+	// 包含 / 的规则相对共享文件夹根目录，否则匹配任意层级的名称
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	expr := globToRegexp(line)
+	if anchored {
+		expr = "^" + expr + "$"
+	} else {
+		expr = "^(?:.*/)?" + expr + "$"
+	}
+	if foldCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return ignoreRule{}, false
+	}
+	rule.re = re
+	return rule, true
+}
+
+// 将 gitignore 通配符转换为正则表达式
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		c := glob[i]
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "/**") && i+3 == len(glob):
+			b.WriteString("/.*")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '\\' && i+1 < len(glob):
+			i++
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		case c == '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// 判断路径本身是否命中规则
+func (ig *IgnoreRules) match(rel string, isDir bool) bool {
+	ignored := false
+	for _, rule := range ig.rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.re.MatchString(rel) {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// 判断相对共享文件夹的路径是否被忽略，上级目录被忽略时其中的内容也被忽略
+func (ig *IgnoreRules) Ignored(rel string, isDir bool) bool {
+	rel = strings.Trim(path.Clean("/"+rel), "/")
+	if rel == "" {
+		return false
+	}
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		last := i == len(parts)-1
+		if ig.match(strings.Join(parts[:i+1], "/"), !last || isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// 规则文件的检查间隔，列表和搜索对每一项都要判断是否隐藏，不必每次都读取规则文件
+const ignoreRecheck = 2 * time.Second
+
+// 共享文件夹的忽略规则，规则文件修改后自动重新加载
+type ignoreCache struct {
+	mu      sync.Mutex
+	modTime time.Time
+	checked time.Time // 上次检查规则文件的时间
+	rules   *IgnoreRules
+}
+
+// 获取共享文件夹当前的忽略规则
+func (t *AppServer) ignoreRules() *IgnoreRules {
+	c := &t.ignore
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	share, err := t.storage()
+	if err != nil {
+		return ParseIgnoreRules(defaultIgnorePatterns, caseInsensitiveFS)
+	}
+	foldCase := storageFoldsCase(share)
+	now := time.Now()
+	if c.rules != nil && c.rules.foldCase == foldCase && now.Sub(c.checked) < ignoreRecheck {
+		return c.rules
+	}
+	c.checked = now
+
+	var modTime time.Time
+	info, err := share.Stat(ignoreFileName)
+	if err == nil {
+		modTime = info.ModTime()
+	}
+	if c.rules != nil && modTime.Equal(c.modTime) && c.rules.foldCase == foldCase {
+		return c.rules
+	}
+
+	lines := append([]string(nil), defaultIgnorePatterns...)
+	if err == nil {
+		if data, err := readStorageFile(share, ignoreFileName); err == nil {
+			scanner := bufio.NewScanner(bytes.NewReader(data))
+			for scanner.Scan() {
+				lines = append(lines, scanner.Text())
+			}
+		}
+	}
+	c.rules = ParseIgnoreRules(lines, foldCase)
+	c.modTime = modTime
+	return c.rules
+}
+
+// 判断共享文件夹中的路径是否对客户端隐藏，列表、搜索和下载都以此为准
+func (t *AppServer) isHidden(rel string, isDir bool) bool {
+	rel = cleanRelPath(rel)
+	rules := t.ignoreRules()
+	if isInternalFile(rel, false) || rules.foldCase && isInternalFile(rel, true) {
+		return true
+	}
+	return rules.Ignored(rel, isDir)
+}
+
+// 存储中的路径是否不区分大小写，本地文件夹取决于系统，S3 等对象存储区分大小写
+func storageFoldsCase(share Storage) bool {
+	_, local := share.(*ShareFS)
+	return local && caseInsensitiveFS
+}
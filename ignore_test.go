@@ -0,0 +1,88 @@
+package main
+
+import (
+	"io/fs"
+	"testing"
+	"time"
+)
+
+func TestIgnoreRulesFoldCase(t *testing.T) {
+	lines := append(append([]string(nil), defaultIgnorePatterns...), "secret/*.PDF")
+	tests := []struct {
+		rel      string
+		foldCase bool
+		want     bool
+	}{
+		{"desktop.ini", false, true},
+		{"DESKTOP.INI", false, false},
+		{"DESKTOP.INI", true, true},
+		{"sub/Desktop.Ini", true, true},
+		{"secret/a.PDF", false, true},
+		{"secret/a.pdf", false, false},
+		{"SECRET/a.pdf", true, true},
+		{"readme.txt", true, false},
+	}
+	for _, tt := range tests {
+		if got := ParseIgnoreRules(lines, tt.foldCase).Ignored(tt.rel, false); got != tt.want {
+			t.Errorf("Ignored(%q, foldCase=%v) = %v, want %v", tt.rel, tt.foldCase, got, tt.want)
+		}
+	}
+}
+
+func TestHiddenCaseInsensitive(t *testing.T) {
+	defer func(v bool) { caseInsensitiveFS = v }(caseInsensitiveFS)
+	s := NewAppServer(t.TempDir())
+
+	caseInsensitiveFS = true
+	for _, rel := range []string{"HISTORY.JSON", "History.json", ".KUAICHUANIGNORE", "DESKTOP.INI", "a/THUMBS.DB"} {
+		if !s.isHidden(rel, false) {
+			t.Errorf("%s is visible on a case-insensitive file system", rel)
+		}
+	}
+	if s.isHidden("photo.JPG", false) {
+		t.Error("photo.JPG hidden")
+	}
+
+	// 区分大小写的文件系统上 HISTORY.JSON 是另一个文件
+	caseInsensitiveFS = false
+	if s.isHidden("HISTORY.JSON", false) || !s.isHidden("history.json", false) {
+		t.Error("case-sensitive file system")
+	}
+}
+
+// 统计读取规则文件的次数
+type ignoreStatCounter struct {
+	*MemStorage
+	stats int
+}
+
+func (s *ignoreStatCounter) Stat(rel string) (fs.FileInfo, error) {
+	if rel == ignoreFileName {
+		s.stats++
+	}
+	return s.MemStorage.Stat(rel)
+}
+
+func TestIgnoreRulesCached(t *testing.T) {
+	s := NewAppServer("")
+	share := &ignoreStatCounter{MemStorage: NewMemStorage()}
+	s.Storage = share
+	writeStorageFile(share, ignoreFileName, []byte("*.log\n"))
+
+	// 列出大量文件时只检查一次规则文件
+	for range 1000 {
+		if !s.isHidden("a.log", false) {
+			t.Fatal("a.log visible")
+		}
+	}
+	if share.stats != 1 {
+		t.Fatalf("rules file checked %d times", share.stats)
+	}
+
+	// 超过检查间隔后读取修改的规则
+	writeStorageFile(share, ignoreFileName, []byte("*.tmp\n"))
+	s.ignore.checked = time.Now().Add(-ignoreRecheck)
+	if s.isHidden("a.log", false) || !s.isHidden("a.tmp", false) {
+		t.Fatal("changed rules not loaded")
+	}
+}
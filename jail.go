@@ -0,0 +1,258 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// 路径中包含符号链接且未允许跟随
+var errSymlink = errors.New("不允许访问符号链接")
+
+// 本地共享文件夹的存储，所有访问都限制在共享文件夹内，路径无法通过 .. 或符号链接逃逸
+type ShareFS struct {
+	root *os.Root
+
+	// 是否跟随共享文件夹内的符号链接，指向共享文件夹外或使用绝对路径的链接始终无法访问
+	FollowSymlinks bool
+}
+
+func OpenShareFS(dir string, followSymlinks bool) (*ShareFS, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &ShareFS{root: root, FollowSymlinks: followSymlinks}, nil
+}
+
+func (s *ShareFS) Close() error {
+	return s.root.Close()
+}
+
+// 规范化相对路径，不跟随符号链接时逐级检查路径中的每一部分
+func (s *ShareFS) check(rel string) (string, error) {
+	rel = cleanRelPath(rel)
+	if rel == "" {
+		return ".", nil
+	}
+	if s.FollowSymlinks {
+		return rel, nil
+	}
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		info, err := s.root.Lstat(strings.Join(parts[:i+1], "/"))
+		if errors.Is(err, fs.ErrNotExist) {
+			break // 尚未创建的部分交给后续操作处理
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return "", &fs.PathError{Op: "open", Path: rel, Err: errSymlink}
+		}
+	}
+	return rel, nil
+}
+
+func (s *ShareFS) Stat(rel string) (fs.FileInfo, error) {
+	rel, err := s.check(rel)
+	if err != nil {
+		return nil, err
+	}
+	return s.root.Stat(rel)
+}
+
+func (s *ShareFS) Open(rel string) (StorageFile, error) {
+	rel, err := s.check(rel)
+	if err != nil {
+		return nil, err
+	}
+	return s.root.Open(rel)
+}
+
+// 创建或覆盖文件，返回的 *os.File 可用于预分配空间
+func (s *ShareFS) Create(rel string) (io.WriteCloser, error) {
+	rel, err := s.check(rel)
+	if err != nil {
+		return nil, err
+	}
+	return s.root.OpenFile(rel, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+}
+
+func (s *ShareFS) Remove(rel string) error {
+	rel, err := s.check(rel)
+	if err != nil {
+		return err
+	}
+	return s.root.Remove(rel)
+}
+
+// 重命名文件或目录，两个路径都经过检查，上级目录中的符号链接始终不允许
+func (s *ShareFS) Rename(from, to string) error {
+	from, err := s.check(from)
+	if err != nil {
+		return err
+	}
+	to, err = s.check(to)
+	if err != nil {
+		return err
+	}
+	if from == "." || to == "." {
+		return &fs.PathError{Op: "rename", Path: from, Err: fs.ErrInvalid}
+	}
+	oldPath, err := s.renamePath(from)
+	if err != nil {
+		return err
+	}
+	newPath, err := s.renamePath(to)
+	if err != nil {
+		return err
+	}
+	return os.Rename(oldPath, newPath)
+}
+
+// 重命名使用的本地路径。os.Rename 按路径操作，不受 root 限制，因此在重命名前
+// 逐级确认上级目录不是符号链接，并通过 root 打开上级目录，与按路径找到的目录比较。
+// Go 1.24 的 os.Root 没有 Rename，检查与重命名之间仍有很短的间隔可能被替换为符号链接
+func (s *ShareFS) renamePath(rel string) (string, error) {
+	dir := path.Dir(rel)
+	if dir != "." {
+		parts := strings.Split(dir, "/")
+		for i := range parts {
+			info, err := s.root.Lstat(strings.Join(parts[:i+1], "/"))
+			if err != nil {
+				return "", err
+			}
+			if info.Mode()&fs.ModeSymlink != 0 {
+				return "", &fs.PathError{Op: "rename", Path: rel, Err: errSymlink}
+			}
+		}
+	}
+
+	f, err := s.root.Open(dir)
+	if err != nil {
+		return "", err
+	}
+	inRoot, err := f.Stat()
+	f.Close()
+	if err != nil {
+		return "", err
+	}
+	osPath := filepath.Join(s.root.Name(), filepath.FromSlash(rel))
+	byPath, err := os.Stat(filepath.Dir(osPath))
+	if err != nil {
+		return "", err
+	}
+	if !inRoot.IsDir() || !os.SameFile(inRoot, byPath) {
+		return "", &fs.PathError{Op: "rename", Path: rel, Err: errSymlink}
+	}
+	return osPath, nil
+}
+
+// 逐级创建目录
+func (s *ShareFS) MkdirAll(rel string) error {
+	rel, err := s.check(rel)
+	if err != nil || rel == "." {
+		return err
+	}
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		err := s.root.Mkdir(strings.Join(parts[:i+1], "/"), 0755)
+		if err != nil && !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// 目录中的一项，符号链接已解析为目标的信息
+type shareEntry struct {
+	fs.FileInfo
+	link bool
+}
+
+func (s *ShareFS) readDir(rel string) ([]shareEntry, error) {
+	rel, err := s.check(rel)
+	if err != nil {
+		return nil, err
+	}
+	f, err := s.root.Open(rel)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	dirEntries, err := f.ReadDir(-1)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]shareEntry, 0, len(dirEntries))
+	for _, d := range dirEntries {
+		if d.Type()&fs.ModeSymlink != 0 {
+			if !s.FollowSymlinks {
+				continue
+			}
+			// 指向共享文件夹外或已失效的链接不显示
+			info, err := s.root.Stat(path.Join(rel, d.Name()))
+			if err != nil {
+				continue
+			}
+			entries = append(entries, shareEntry{FileInfo: info, link: true})
+			continue
+		}
+		info, err := d.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, shareEntry{FileInfo: info})
+	}
+	return entries, nil
+}
+
+// 读取目录，不可访问的符号链接会被跳过
+func (s *ShareFS) List(rel string) ([]fs.FileInfo, error) {
+	entries, err := s.readDir(rel)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]fs.FileInfo, len(entries))
+	for i, e := range entries {
+		infos[i] = e.FileInfo
+	}
+	return infos, nil
+}
+
+// 递归遍历目录，为避免循环不进入链接的目录
+func (s *ShareFS) Walk(rel string, fn WalkFunc) error {
+	err := s.walk(cleanRelPath(rel), fn)
+	if err == fs.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func (s *ShareFS) walk(dir string, fn WalkFunc) error {
+	entries, err := s.readDir(dir)
+	if err != nil {
+		return nil // 忽略无法访问的目录
+	}
+	for _, e := range entries {
+		rel := path.Join(dir, e.Name())
+		if err := fn(rel, e.FileInfo); err != nil {
+			if err == fs.SkipDir && e.IsDir() {
+				continue
+			}
+			return err
+		}
+		if e.IsDir() && !e.link {
+			if err := s.walk(rel, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
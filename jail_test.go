@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestShareFSRenameSymlinkedParent(t *testing.T) {
+	outside := t.TempDir()
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644)
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "real"), 0755)
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("a"), 0644)
+	os.Symlink(outside, filepath.Join(dir, "out"))
+	os.Symlink("real", filepath.Join(dir, "inlink"))
+
+	// 跟随符号链接时也不能经由上级目录中的链接重命名
+	s, err := OpenShareFS(dir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for _, c := range [][2]string{
+		{"a.txt", "out/a.txt"},
+		{"out/secret.txt", "stolen.txt"},
+		{"a.txt", "inlink/a.txt"},
+	} {
+		if err := s.Rename(c[0], c[1]); !errors.Is(err, errSymlink) {
+			t.Errorf("Rename(%s, %s) = %v", c[0], c[1], err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outside, "a.txt")); err == nil {
+		t.Fatal("file moved out of the share")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "secret.txt")); err != nil {
+		t.Fatal("file moved into the share")
+	}
+
+	if err := s.Rename("a.txt", "real/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "real", "b.txt")); string(data) != "a" {
+		t.Fatalf("renamed content = %q", data)
+	}
+}
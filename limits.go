@@ -0,0 +1,198 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+)
+
+// multipart 表单除文件内容外的额外开销（分隔符、字段头等）
+const multipartSlack = 8 << 10
+
+// 上传限制配置，数值单位为字节，0 表示不限制
+type UploadLimits struct {
+	MaxFileSize  int64 `json:"maxFileSize"`  // 单个文件最大大小
+	ClientQuota  int64 `json:"clientQuota"`  // 每个客户端本次共享期间可上传的总大小
+	ShareQuota   int64 `json:"shareQuota"`   // 共享文件夹总大小上限
+	MinFreeSpace int64 `json:"minFreeSpace"` // 上传后磁盘至少保留的空闲空间
+	Preallocate  bool  `json:"preallocate"`  // 接收前按声明大小预分配磁盘空间
+}
+
+// 本次上传的额度，超出时返回对应的状态码和错误
+type uploadAllowance struct {
+	Bytes  int64 // 最多可写入的字节数，-1 表示不限制
+	Status int
+	Error  string // 错误码，同时是提示文本的消息ID
+	Args   []any  // 提示文本的参数
+}
+
+// 用更严格的限制收紧额度
+func (a *uploadAllowance) tighten(bytes int64, status int, id string, args ...any) {
+	if bytes < 0 {
+		bytes = 0
+	}
+	if a.Bytes < 0 || bytes < a.Bytes {
+		a.Bytes = bytes
+		a.Status = status
+		a.Error = id
+		a.Args = args
+	}
+}
+
+// 是否允许写入 size 字节
+func (a uploadAllowance) allows(size int64) bool {
+	return a.Bytes < 0 || size <= a.Bytes
+}
+
+// 计算客户端当前可上传的额度
+func (t *AppServer) uploadAllowance(ip string) uploadAllowance {
+	a := uploadAllowance{Bytes: -1}
+	limits := t.Limits
+
+	if limits.MaxFileSize > 0 {
+		a.tighten(limits.MaxFileSize, http.StatusRequestEntityTooLarge,
+			"limit_file_size", formatFileSize(limits.MaxFileSize))
+	}
+
+	if limits.ClientQuota > 0 {
+		t.mu.Lock()
+		used := t.clientUsage[ip]
+		t.mu.Unlock()
+		a.tighten(limits.ClientQuota-used, http.StatusRequestEntityTooLarge,
+			"limit_client_quota", formatFileSize(limits.ClientQuota))
+	}
+
+	if limits.ShareQuota > 0 {
+		if share, err := t.storage(); err == nil {
+			used := dirSize(share)
+			a.tighten(limits.ShareQuota-used, http.StatusInsufficientStorage,
+				"limit_share_quota", formatFileSize(limits.ShareQuota))
+		}
+	}
+
+	if free, err := t.diskFree(); err == nil {
+		a.tighten(free-limits.MinFreeSpace, http.StatusInsufficientStorage, "limit_disk_full")
+	}
+
+	return a
+}
+
+// 在写入前预留客户端的额度，超出时不预留并返回 false。
+// 检查和记录在同一次加锁中完成，同一客户端的并发上传不会同时通过检查
+func (t *AppServer) reserveClientUsage(ip string, n int64) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if quota := t.Limits.ClientQuota; quota > 0 && t.clientUsage[ip]+n > quota {
+		return false
+	}
+	if t.clientUsage == nil {
+		t.clientUsage = make(map[string]int64)
+	}
+	t.clientUsage[ip] += n
+	return true
+}
+
+// 退回上传失败时预留的额度
+func (t *AppServer) releaseClientUsage(ip string, n int64) {
+	if n == 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.clientUsage[ip] -= n
+}
+
+var errClientQuota = errors.New("超出客户端上传额度")
+
+// 读取上传内容时按客户端额度预留，超出时停止读取
+type quotaReader struct {
+	r        io.Reader
+	t        *AppServer
+	ip       string
+	reserved int64
+	exceeded bool
+}
+
+func (q *quotaReader) Read(p []byte) (int, error) {
+	n, err := q.r.Read(p)
+	if n > 0 && !q.t.reserveClientUsage(q.ip, int64(n)) {
+		q.exceeded = true
+		return 0, errClientQuota
+	}
+	q.reserved += int64(n)
+	return n, err
+}
+
+func (q *quotaReader) release() {
+	q.t.releaseClientUsage(q.ip, q.reserved)
+	q.reserved = 0
+}
+
+// 获取上传限制的处理函数
+func (t *AppServer) limitsHandler(w http.ResponseWriter, r *http.Request) {
+	allowance := t.uploadAllowance(clientIP(r))
+
+	var freeSpace int64 = -1
+	if free, err := t.diskFree(); err == nil {
+		freeSpace = free
+	}
+
+	writeResult(w, r, http.StatusOK, "ok", map[string]any{
+		"limits":    t.Limits,
+		"allowance": allowance.Bytes,
+		"freeSpace": freeSpace,
+	})
+}
+
+// 获取客户端IP地址
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// 共享文件夹所在磁盘的剩余空间，仅适用于本地存储
+func (t *AppServer) diskFree() (int64, error) {
+	share, err := t.storage()
+	if err != nil {
+		return 0, err
+	}
+	if _, ok := share.(*ShareFS); !ok {
+		return 0, errors.ErrUnsupported
+	}
+	return diskFree(t.UploadDir)
+}
+
+// 统计共享文件夹中所有文件的总大小
+func dirSize(share Storage) int64 {
+	var size int64
+	share.Walk("", func(rel string, info fs.FileInfo) error {
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// 格式化文件大小
+func formatFileSize(bytes int64) string {
+	if bytes < 1024 {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	units := []string{"KB", "MB", "GB", "TB"}
+	size := float64(bytes) / 1024
+	unitIndex := 0
+	for size >= 1024 && unitIndex < len(units)-1 {
+		size /= 1024
+		unitIndex++
+	}
+
+	return fmt.Sprintf("%.2f %s", size, units[unitIndex])
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFailedUploadKeepsFile(t *testing.T) {
+	for _, mem := range []bool{false, true} {
+		dir := t.TempDir()
+		s := NewAppServer(dir)
+		if mem {
+			s.Storage = NewMemStorage()
+		}
+		share, _ := s.storage()
+		writeStorageFile(share, "a.txt", []byte("old"))
+		h := s.Handler()
+
+		// 超出大小限制时原文件不变，也不留下临时文件
+		s.Limits.MaxFileSize = 10
+		if rec := postUpload(h, "a.txt", strings.Repeat("x", 100)); rec.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("mem=%v: too large: %d", mem, rec.Code)
+		}
+		if data, _ := readStorageFile(share, "a.txt"); string(data) != "old" {
+			t.Fatalf("mem=%v: file changed to %q", mem, data)
+		}
+		infos, _ := share.List("")
+		if len(infos) != 1 {
+			t.Fatalf("mem=%v: %d files left", mem, len(infos))
+		}
+
+		s.Limits.MaxFileSize = 0
+		if rec := postUpload(h, "a.txt", "new"); rec.Code != http.StatusOK {
+			t.Fatalf("mem=%v: replace: %d %s", mem, rec.Code, rec.Body.String())
+		}
+		if data, _ := readStorageFile(share, "a.txt"); string(data) != "new" {
+			t.Fatalf("mem=%v: replaced content %q", mem, data)
+		}
+
+		// 同名的文件夹不会被替换
+		share.MkdirAll("docs")
+		if rec := postUpload(h, "docs", "x"); rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "upload_target_is_dir") {
+			t.Fatalf("mem=%v: upload onto folder: %d %s", mem, rec.Code, rec.Body.String())
+		}
+		writeStorageFile(share, ".part", []byte("x"))
+		if err := replacePart(share, ".part", "docs"); err != errTargetIsDir {
+			t.Fatalf("mem=%v: replacePart onto folder: %v", mem, err)
+		}
+		if info, err := share.Stat("docs"); err != nil || !info.IsDir() {
+			t.Fatalf("mem=%v: folder replaced: %v", mem, err)
+		}
+	}
+}
+
+func TestClientQuotaConcurrentUploads(t *testing.T) {
+	dir := t.TempDir()
+	s := NewAppServer(dir)
+	s.Limits.ClientQuota = 1000
+	h := s.Handler()
+
+	// 所有上传都先通过预先检查，再同时写入
+	const n = 5
+	var wg sync.WaitGroup
+	codes := make([]int, n)
+	for i := range n {
+		pr, pw := io.Pipe()
+		var head bytes.Buffer
+		mw := multipart.NewWriter(&head)
+		req := httptest.NewRequest("POST", "/api/upload", pr)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			codes[i] = rec.Code
+			pr.Close()
+		}()
+		go func() {
+			fw, _ := mw.CreateFormFile("file", string(rune('a'+i))+".txt")
+			pw.Write(head.Bytes())
+			head.Reset()
+			time.Sleep(50 * time.Millisecond)
+			fw.Write(bytes.Repeat([]byte("x"), 400))
+			mw.Close()
+			pw.Write(head.Bytes())
+			pw.Close()
+		}()
+	}
+	wg.Wait()
+
+	var ok int
+	for _, code := range codes {
+		if code == http.StatusOK {
+			ok++
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	var total int64
+	for _, e := range entries {
+		if info, _ := e.Info(); info != nil && filepath.Ext(e.Name()) == ".txt" {
+			total += info.Size()
+		}
+	}
+	if ok != 2 || total != 800 || s.clientUsage["192.0.2.1"] != 800 {
+		t.Fatalf("codes %v, %d bytes saved, usage %d", codes, total, s.clientUsage["192.0.2.1"])
+	}
+}
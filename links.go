@@ -0,0 +1,735 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 分享链接的访问前缀，如 /s/abc123
+const linkPrefix = "/s/"
+
+// 保存已输入密码的 cookie 名称前缀，后接链接的 token
+const linkCookiePrefix = "kc_link_"
+
+var (
+	errLinkNotFound  = errors.New("link_not_found")
+	errLinkExpired   = errors.New("link_expired")
+	errLinkExhausted = errors.New("link_exhausted")
+	errLinkFull      = errors.New("link_full")
+)
+
+// 链接类型
+type LinkKind string
+
+const (
+	LinkDownload LinkKind = ""        // 分享文件或文件夹供下载
+	LinkRequest  LinkKind = "request" // 收集文件，只能上传到指定文件夹
+	LinkTemp     LinkKind = "temp"    // 临时分享拖入窗口的文件，不在共享文件夹中，退出后失效
+)
+
+// 分享链接或收集链接，共享模式为只读或仅上传时也能访问
+type ShareLink struct {
+	Token        string    `json:"token"`
+	Kind         LinkKind  `json:"kind,omitempty"`
+	Share        string    `json:"share"` // 创建时的共享文件夹，更换共享文件夹后链接失效
+	Path         string    `json:"path"`  // 相对共享文件夹的路径
+	IsDir        bool      `json:"isDir"`
+	Created      time.Time `json:"created"`
+	Expires      time.Time `json:"expires"`      // 为零表示不过期
+	MaxDownloads int       `json:"maxDownloads"` // 0 表示不限次数
+	Downloads    int       `json:"downloads"`
+	Salt         string    `json:"salt,omitempty"`
+	PasswordHash string    `json:"passwordHash,omitempty"` // 为空表示不需要密码
+
+	// 收集链接的限制，0 或为空表示不限制
+	MaxFiles     int      `json:"maxFiles,omitempty"`
+	MaxFileSize  int64    `json:"maxFileSize,omitempty"`
+	AllowedTypes []string `json:"allowedTypes,omitempty"` // 允许的扩展名，如 .pdf，或 image/* 这样的类型
+	Uploads      int      `json:"uploads,omitempty"`      // 已收到的文件数
+
+	// 临时分享的本地文件和文件夹，Path 为其中的相对路径
+	Files []string `json:"files,omitempty"`
+}
+
+// 链接显示的名称
+func (l ShareLink) Name() string {
+	if l.Kind == LinkTemp && l.Path == "" {
+		return strings.Join(dropNames(l.Files), ", ")
+	}
+	if l.Path == "" {
+		return "/"
+	}
+	return path.Base(l.Path)
+}
+
+// 是否需要密码
+func (l ShareLink) Protected() bool {
+	return l.PasswordHash != ""
+}
+
+// 链接是否仍可使用，失效时返回原因
+func (l ShareLink) check(now time.Time) error {
+	if !l.Expires.IsZero() && now.After(l.Expires) {
+		return errLinkExpired
+	}
+	if l.Kind == LinkRequest {
+		if l.MaxFiles > 0 && l.Uploads >= l.MaxFiles {
+			return errLinkFull
+		}
+		return nil
+	}
+	if l.MaxDownloads > 0 && l.Downloads >= l.MaxDownloads {
+		return errLinkExhausted
+	}
+	return nil
+}
+
+// 剩余下载次数，收集链接为还可上传的文件数，-1 表示不限
+func (l ShareLink) Remaining() int {
+	limit, used := l.MaxDownloads, l.Downloads
+	if l.Kind == LinkRequest {
+		limit, used = l.MaxFiles, l.Uploads
+	}
+	if limit <= 0 {
+		return -1
+	}
+	return max(limit-used, 0)
+}
+
+// 收集链接是否接受该类型的文件
+func (l ShareLink) allowsType(name string) bool {
+	if len(l.AllowedTypes) == 0 {
+		return true
+	}
+	ext := strings.ToLower(path.Ext(name))
+	typ, _, _ := strings.Cut(mime.TypeByExtension(ext), ";")
+	for _, allowed := range l.AllowedTypes {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		switch {
+		case allowed == "":
+		case strings.HasSuffix(allowed, "/*"):
+			if strings.HasPrefix(typ, strings.TrimSuffix(allowed, "*")) {
+				return true
+			}
+		case strings.Contains(allowed, "/"):
+			if typ == allowed {
+				return true
+			}
+		case ext != "" && strings.TrimPrefix(allowed, ".") == ext[1:]:
+			return true
+		}
+	}
+	return false
+}
+
+// 检查密码
+func (l ShareLink) checkPassword(password string) bool {
+	salt, err := hex.DecodeString(l.Salt)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(hashLinkPassword(password, salt)), []byte(l.PasswordHash)) == 1
+}
+
+func hashLinkPassword(password string, salt []byte) string {
+	key, _ := pbkdf2.Key(sha256.New, password, salt, 100000, 32)
+	return hex.EncodeToString(key)
+}
+
+// 分享链接列表，保存到配置中，跨多次共享保留
+type LinkRegistry struct {
+	mu        sync.Mutex
+	links     map[string]*ShareLink
+	downloads map[string]*linkDownload // 进行中的下载，键为链接、客户端和文件
+	secret    []byte                   // 签名 cookie 的密钥，每次运行重新生成，重启后需要重新输入密码
+
+	OnSave func() // 链接新建、撤销或下载次数变化时调用，用于保存配置
+}
+
+func NewLinkRegistry() *LinkRegistry {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return &LinkRegistry{
+		links:     make(map[string]*ShareLink),
+		downloads: make(map[string]*linkDownload),
+		secret:    secret,
+	}
+}
+
+// 载入保存的链接
+func (l *LinkRegistry) Load(saved []ShareLink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, s := range saved {
+		link := s
+		l.links[s.Token] = &link
+	}
+}
+
+// 需要保存的链接，临时分享不保存
+func (l *LinkRegistry) Saved() []ShareLink {
+	return slices.DeleteFunc(l.List(), func(link ShareLink) bool { return link.Kind == LinkTemp })
+}
+
+// 所有链接，最新创建的在前
+func (l *LinkRegistry) List() []ShareLink {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	list := make([]ShareLink, 0, len(l.links))
+	for _, link := range l.links {
+		list = append(list, *link)
+	}
+	slices.SortFunc(list, func(a, b ShareLink) int { return b.Created.Compare(a.Created) })
+	return list
+}
+
+// 获取链接
+func (l *LinkRegistry) Get(token string) (ShareLink, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	link, ok := l.links[token]
+	if !ok {
+		return ShareLink{}, false
+	}
+	return *link, true
+}
+
+// 按 link 中的类型、路径和限制新建链接，password 为空表示不需要密码
+func (l *LinkRegistry) Create(link ShareLink, password string) ShareLink {
+	token := make([]byte, 12)
+	rand.Read(token)
+	link.Token = base64.RawURLEncoding.EncodeToString(token)
+	link.Path = cleanRelPath(link.Path)
+	link.Created = time.Now()
+	link.Downloads, link.Uploads = 0, 0
+	if link.Kind == LinkRequest {
+		link.IsDir = true
+	}
+	if password != "" {
+		salt := make([]byte, 16)
+		rand.Read(salt)
+		link.Salt = hex.EncodeToString(salt)
+		link.PasswordHash = hashLinkPassword(password, salt)
+	}
+
+	l.mu.Lock()
+	l.links[link.Token] = &link
+	l.mu.Unlock()
+	l.save()
+	return link
+}
+
+// 撤销链接
+func (l *LinkRegistry) Revoke(token string) {
+	l.mu.Lock()
+	delete(l.links, token)
+	l.mu.Unlock()
+	l.save()
+}
+
+// 断点续传或分段下载可以继续使用同一次下载的时间
+const linkResumeWindow = 24 * time.Hour
+
+// 同一客户端对一个文件的下载，分段和续传的请求共用。每占用一次下载次数可以读取一个文件大小的内容，
+// 用完后继续读取时再占用一次，链接的次数用完后停止发送
+type linkDownload struct {
+	token     string
+	size      int64
+	remaining int64
+	expires   time.Time
+}
+
+// 开始下载 key 对应的文件：同一客户端未用完的下载继续使用，否则检查并占用一次下载次数。
+// reserved 表示是否新占用了次数，没有发送内容时用 releaseDownload 归还
+func (l *LinkRegistry) startDownload(token, key string, size int64) (d *linkDownload, reserved bool, err error) {
+	l.mu.Lock()
+	now := time.Now()
+	for k, d := range l.downloads {
+		if d.remaining <= 0 || now.After(d.expires) {
+			delete(l.downloads, k)
+		}
+	}
+	if d, ok := l.downloads[key]; ok {
+		l.mu.Unlock()
+		return d, false, nil
+	}
+	link, ok := l.links[token]
+	if !ok {
+		l.mu.Unlock()
+		return nil, false, errLinkNotFound
+	}
+	if err := link.check(now); err != nil {
+		l.mu.Unlock()
+		return nil, false, err
+	}
+	link.Downloads++
+	d = &linkDownload{token: token, size: size, remaining: size, expires: now.Add(linkResumeWindow)}
+	l.downloads[key] = d
+	l.mu.Unlock()
+	l.save()
+	return d, true, nil
+}
+
+// 归还新占用的下载次数
+func (l *LinkRegistry) releaseDownload(token, key string, d *linkDownload) {
+	l.mu.Lock()
+	if link, ok := l.links[token]; ok {
+		link.Downloads--
+	}
+	if l.downloads[key] == d {
+		delete(l.downloads, key)
+	}
+	l.mu.Unlock()
+	l.save()
+}
+
+// 从下载中取出最多 n 字节的额度，不足时再占用一次下载次数，返回取到的字节数；n 为负数时退回
+func (l *LinkRegistry) take(d *linkDownload, n int64) int64 {
+	l.mu.Lock()
+	extended := false
+	if n > d.remaining {
+		if link, ok := l.links[d.token]; ok && link.check(time.Now()) == nil {
+			link.Downloads++
+			d.remaining += d.size
+			extended = true
+		}
+	}
+	n = min(n, d.remaining)
+	d.remaining -= n
+	l.mu.Unlock()
+	if extended {
+		l.save()
+	}
+	return n
+}
+
+// 为收集链接占用一个文件名额，上传失败时调用返回的函数归还
+func (l *LinkRegistry) reserveUpload(token string) (func(), error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	link, ok := l.links[token]
+	if !ok {
+		return nil, errLinkNotFound
+	}
+	if err := link.check(time.Now()); err != nil {
+		return nil, err
+	}
+	link.Uploads++
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		link.Uploads--
+	}, nil
+}
+
+func (l *LinkRegistry) save() {
+	if l.OnSave != nil {
+		l.OnSave()
+	}
+}
+
+// 输入密码后保存在 cookie 中的值
+func (l *LinkRegistry) unlockValue(link ShareLink) string {
+	mac := hmac.New(sha256.New, l.secret)
+	mac.Write([]byte(link.Token + "|" + link.PasswordHash))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// 请求是否可以访问链接，需要密码时检查 cookie
+func (l *LinkRegistry) unlocked(r *http.Request, link ShareLink) bool {
+	if !link.Protected() {
+		return true
+	}
+	c, err := r.Cookie(linkCookiePrefix + link.Token)
+	return err == nil && hmac.Equal([]byte(c.Value), []byte(l.unlockValue(link)))
+}
+
+// 查找链接，不存在、已更换共享文件夹或已失效时返回错误响应
+func (t *AppServer) findLink(w http.ResponseWriter, r *http.Request) (ShareLink, bool) {
+	link, err := t.lookupLink(r)
+	if err != nil {
+		writeLinkError(w, r, err)
+		return link, false
+	}
+	return link, true
+}
+
+// 查找链接，不存在或已更换共享文件夹时返回 errLinkNotFound，已失效时返回原因
+func (t *AppServer) lookupLink(r *http.Request) (ShareLink, error) {
+	var link ShareLink
+	ok := t.Links != nil
+	if ok {
+		link, ok = t.Links.Get(r.PathValue("token"))
+	}
+	if !ok || (link.Kind != LinkTemp && link.Share != t.UploadDir) || t.linkHidden(link, link.Path, link.IsDir) {
+		return link, errLinkNotFound
+	}
+	return link, link.check(time.Now())
+}
+
+func writeLinkError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, errLinkNotFound) {
+		writeError(w, r, http.StatusNotFound, err.Error())
+		return
+	}
+	writeError(w, r, http.StatusGone, err.Error())
+}
+
+// 链接使用的存储，临时分享读取拖入的文件，其他链接读取共享文件夹
+func (t *AppServer) linkStorage(link ShareLink) (Storage, error) {
+	if link.Kind == LinkTemp {
+		return NewDropStorage(link.Files, t.FollowSymlinks), nil
+	}
+	return t.storage()
+}
+
+// 路径是否被隐藏，临时分享的文件不在共享文件夹中，不使用共享文件夹的忽略规则
+func (t *AppServer) linkHidden(link ShareLink, rel string, isDir bool) bool {
+	return link.Kind != LinkTemp && t.isHidden(rel, isDir)
+}
+
+// 链接中的文件路径，文件夹链接可通过 path 访问其中的文件
+func linkTarget(link ShareLink, sub string) string {
+	if !link.IsDir {
+		return link.Path
+	}
+	return cleanRelPath(path.Join(link.Path, cleanRelPath(sub)))
+}
+
+// 分享链接页面，收集链接显示上传页面
+func (t *AppServer) linkPage(w http.ResponseWriter, r *http.Request) {
+	if t.Links != nil {
+		if link, ok := t.Links.Get(r.PathValue("token")); ok && link.Kind == LinkRequest {
+			webAssets().servePage(w, r, "request.html")
+			return
+		}
+	}
+	webAssets().servePage(w, r, "share.html")
+}
+
+// 链接信息，文件夹链接在输入密码后同时返回 path 下的文件
+func (t *AppServer) linkInfoHandler(w http.ResponseWriter, r *http.Request) {
+	link, ok := t.findLink(w, r)
+	if !ok {
+		return
+	}
+	unlocked := t.Links.unlocked(r, link)
+	data := map[string]any{
+		"name":      link.Name(),
+		"type":      "file",
+		"expires":   link.Expires,
+		"remaining": link.Remaining(),
+		"protected": link.Protected(),
+		"unlocked":  unlocked,
+	}
+	if link.Expires.IsZero() {
+		data["expires"] = nil
+	}
+	if link.IsDir {
+		data["type"] = "folder"
+	}
+	// 收集链接不能查看文件夹中的内容
+	if link.Kind == LinkRequest {
+		data["type"] = "request"
+		data["maxFileSize"] = link.MaxFileSize
+		data["allowedTypes"] = append([]string{}, link.AllowedTypes...)
+		writeResult(w, r, http.StatusOK, "ok", data)
+		return
+	}
+	if !unlocked {
+		writeResult(w, r, http.StatusOK, "ok", data)
+		return
+	}
+
+	share, err := t.linkStorage(link)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, "link_not_found")
+		return
+	}
+	if !link.IsDir {
+		info, err := share.Stat(link.Path)
+		if err != nil || info.IsDir() {
+			writeError(w, r, http.StatusNotFound, "file_not_found")
+			return
+		}
+		data["size"] = info.Size()
+		writeResult(w, r, http.StatusOK, "ok", data)
+		return
+	}
+
+	dir := linkTarget(link, r.URL.Query().Get("path"))
+	entries, err := share.List(dir)
+	if err != nil || t.linkHidden(link, dir, true) {
+		writeError(w, r, http.StatusNotFound, "dir_not_found")
+		return
+	}
+	items := []map[string]any{}
+	for _, entry := range entries {
+		if t.linkHidden(link, path.Join(dir, entry.Name()), entry.IsDir()) {
+			continue
+		}
+		item := map[string]any{"name": entry.Name(), "type": "file", "size": entry.Size()}
+		if entry.IsDir() {
+			item["type"] = "folder"
+			item["size"] = 0
+		}
+		items = append(items, item)
+	}
+	data["list"] = items
+	writeResult(w, r, http.StatusOK, "ok", data)
+}
+
+// 输入链接密码，正确时保存到 cookie
+func (t *AppServer) linkUnlockHandler(w http.ResponseWriter, r *http.Request) {
+	link, ok := t.findLink(w, r)
+	if !ok {
+		return
+	}
+	var body struct {
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, r, http.StatusBadRequest, "bad_request")
+		return
+	}
+	if link.Protected() && !link.checkPassword(body.Password) {
+		writeError(w, r, http.StatusForbidden, "link_password_wrong")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     linkCookiePrefix + link.Token,
+		Value:    t.Links.unlockValue(link),
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	writeResult(w, r, http.StatusOK, "ok", nil)
+}
+
+// 通过链接下载文件，每次下载最多发送一个文件大小的内容，
+// 同一客户端的断点续传和分段下载在用完之前不重复计数
+func (t *AppServer) linkDownloadHandler(w http.ResponseWriter, r *http.Request) {
+	// 次数用完时仍可以继续之前的下载，开始下载时再检查
+	link, linkErr := t.lookupLink(r)
+	if linkErr != nil && !errors.Is(linkErr, errLinkExhausted) {
+		writeLinkError(w, r, linkErr)
+		return
+	}
+	if link.Kind == LinkRequest {
+		writeError(w, r, http.StatusNotFound, "link_not_found")
+		return
+	}
+	if !t.Links.unlocked(r, link) {
+		writeError(w, r, http.StatusUnauthorized, "link_password_required")
+		return
+	}
+
+	rel := linkTarget(link, r.URL.Query().Get("path"))
+	if e := auditEntry(r); e != nil {
+		e.Path = "/" + rel
+	}
+	if t.linkHidden(link, rel, false) {
+		writeError(w, r, http.StatusNotFound, "file_not_found")
+		return
+	}
+	share, err := t.linkStorage(link)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, "file_not_found")
+		return
+	}
+	f, err := share.Open(rel)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, "file_not_found")
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		writeError(w, r, http.StatusNotFound, "file_not_found")
+		return
+	}
+
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": info.Name()}))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if r.Method != http.MethodGet {
+		if linkErr != nil {
+			writeLinkError(w, r, linkErr)
+			return
+		}
+		http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+		return
+	}
+
+	// 开始时占用下载次数，同时进行的请求不会超出限制
+	key := link.Token + "\x00" + clientIP(r) + "\x00" + rel
+	d, reserved, err := t.Links.startDownload(link.Token, key, info.Size())
+	if err != nil {
+		writeLinkError(w, r, err)
+		return
+	}
+	var sent int64
+	sw := &statusWriter{countingWriter: countingWriter{ResponseWriter: w, count: func(n int64) { sent += n }}}
+	http.ServeContent(sw, r, info.Name(), info.ModTime(), &downloadReader{ReadSeeker: f, links: t.Links, d: d})
+
+	// 没有发送任何内容时归还
+	code := sw.code()
+	if reserved && (code != http.StatusOK && code != http.StatusPartialContent || sent == 0 && info.Size() > 0) {
+		t.Links.releaseDownload(link.Token, key, d)
+	}
+}
+
+// 按下载的额度读取文件，链接的次数也用完后返回 errLinkExhausted
+type downloadReader struct {
+	io.ReadSeeker
+	links *LinkRegistry
+	d     *linkDownload
+}
+
+func (r *downloadReader) Read(p []byte) (int, error) {
+	allowed := r.links.take(r.d, int64(len(p)))
+	if allowed <= 0 {
+		return 0, errLinkExhausted
+	}
+	n, err := r.ReadSeeker.Read(p[:allowed])
+	if int64(n) < allowed {
+		r.links.take(r.d, int64(n)-allowed)
+	}
+	return n, err
+}
+
+// 通过收集链接上传一个文件，表单中 name、note 字段需在 file 之前；
+// 文件保存到链接的文件夹，同名时自动改名，不会覆盖已有的文件
+func (t *AppServer) linkUploadHandler(w http.ResponseWriter, r *http.Request) {
+	link, ok := t.findLink(w, r)
+	if !ok {
+		return
+	}
+	if link.Kind != LinkRequest {
+		writeError(w, r, http.StatusNotFound, "link_not_found")
+		return
+	}
+	if !t.Links.unlocked(r, link) {
+		writeError(w, r, http.StatusUnauthorized, "link_password_required")
+		return
+	}
+
+	allowance := t.uploadAllowance(clientIP(r))
+	if link.MaxFileSize > 0 {
+		allowance.tighten(link.MaxFileSize, http.StatusRequestEntityTooLarge,
+			"limit_file_size", formatFileSize(link.MaxFileSize))
+	}
+	if r.ContentLength > 0 && !allowance.allows(r.ContentLength-multipartSlack) {
+		writeError(w, r, allowance.Status, allowance.Error, allowance.Args...)
+		return
+	}
+
+	part, fields, id := readUploadForm(r, "name", "note")
+	if id != "" {
+		writeError(w, r, http.StatusBadRequest, id)
+		return
+	}
+	defer part.Close()
+
+	filename := t.sanitizeFilename(path.Base(filepath.ToSlash(part.FileName())))
+	if !link.allowsType(filename) {
+		writeError(w, r, http.StatusUnsupportedMediaType, "link_type_not_allowed", strings.Join(link.AllowedTypes, ", "))
+		return
+	}
+	dstPath := path.Join(link.Path, filename)
+	if t.isHidden(dstPath, false) {
+		writeError(w, r, http.StatusForbidden, "upload_forbidden_path")
+		return
+	}
+	if e := auditEntry(r); e != nil {
+		e.Path = "/" + dstPath
+	}
+
+	release, err := t.Links.reserveUpload(link.Token)
+	if err != nil {
+		writeError(w, r, http.StatusGone, err.Error())
+		return
+	}
+	// 保存时才选择不重名的文件名，同时上传的同名文件不会互相覆盖
+	dstPath, written, ok := t.saveUpload(w, r, part, dstPath, allowance, true)
+	if !ok {
+		release()
+		return
+	}
+	t.Links.save()
+
+	history := FileInfo{
+		Name:       dstPath,
+		Size:       written,
+		UploadedAt: time.Now().Format("2006-01-02 15:04:05"),
+		Uploader:   fields["name"],
+		Note:       fields["note"],
+		Link:       link.Token,
+	}
+	t.fileReceived(history, t.deviceName(r))
+
+	writeResult(w, r, http.StatusCreated, T(requestLang(r), "msg.upload_ok"), map[string]any{
+		"name": path.Base(dstPath),
+	})
+}
+
+// 收集链接中姓名、备注等文本字段的最大长度
+const maxFormField = 4 << 10
+
+// 以流的方式读取上传表单，返回 file 字段及其之前的文本字段；出错时返回错误码
+func readUploadForm(r *http.Request, names ...string) (*multipart.Part, map[string]string, string) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, nil, "bad_request"
+	}
+	fields := make(map[string]string)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, nil, "missing_file"
+		}
+		if err != nil {
+			return nil, nil, "bad_request"
+		}
+		if part.FormName() == "file" {
+			return part, fields, ""
+		}
+		if slices.Contains(names, part.FormName()) {
+			data, _ := io.ReadAll(io.LimitReader(part, maxFormField))
+			fields[part.FormName()] = strings.TrimSpace(string(data))
+		}
+		part.Close()
+	}
+}
+
+// 目标文件已存在时在文件名后加上序号，如 a (1).txt
+func uniqueFilePath(share Storage, rel string) string {
+	if _, err := share.Stat(rel); err != nil {
+		return rel
+	}
+	ext := path.Ext(rel)
+	base := strings.TrimSuffix(rel, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s (%d)%s", base, i, ext)
+		if _, err := share.Stat(candidate); err != nil {
+			return candidate
+		}
+	}
+}
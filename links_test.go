@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 创建分享 a.txt 的链接，返回按 Range 和客户端地址下载的函数
+func newLinkDownloadTest(t *testing.T, maxDownloads int) (*AppServer, ShareLink, func(rng, addr string) *httptest.ResponseRecorder) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("0123456789"), 0644)
+	s := NewAppServer(dir)
+	s.Links = NewLinkRegistry()
+	h := s.Handler()
+	link := s.Links.Create(ShareLink{Share: dir, Path: "a.txt", MaxDownloads: maxDownloads}, "")
+
+	get := func(rng, addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/links/"+link.Token+"/download", nil)
+		if rng != "" {
+			req.Header.Set("Range", rng)
+		}
+		if addr != "" {
+			req.RemoteAddr = addr
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	return s, link, get
+}
+
+func TestLinkDownloadCountsRanges(t *testing.T) {
+	s, link, get := newLinkDownloadTest(t, 2)
+	downloads := func() int {
+		l, _ := s.Links.Get(link.Token)
+		return l.Downloads
+	}
+
+	// 无法满足的范围不计数
+	if rec := get("bytes=100-", ""); rec.Code != http.StatusRequestedRangeNotSatisfiable || downloads() != 0 {
+		t.Fatalf("unsatisfiable: %d, downloads %d", rec.Code, downloads())
+	}
+	// 分段下载在开始时计数一次，之后的分段继续使用
+	if rec := get("bytes=0-4", ""); rec.Code != http.StatusPartialContent || downloads() != 1 {
+		t.Fatalf("first part: %d, downloads %d", rec.Code, downloads())
+	}
+	if rec := get("bytes=5-", ""); rec.Code != http.StatusPartialContent || rec.Body.String() != "56789" || downloads() != 1 {
+		t.Fatalf("second part: %d %q, downloads %d", rec.Code, rec.Body.String(), downloads())
+	}
+	if rec := get("", ""); rec.Code != http.StatusOK || downloads() != 2 {
+		t.Fatalf("full: %d, downloads %d", rec.Code, downloads())
+	}
+	if rec := get("bytes=5-", ""); rec.Code != http.StatusGone {
+		t.Fatalf("exhausted link served %d", rec.Code)
+	}
+}
+
+func TestLinkDownloadWithoutLastByte(t *testing.T) {
+	_, _, get := newLinkDownloadTest(t, 2)
+
+	// 每次少取最后一个字节，总共也只能取到两次下载的内容
+	var received int
+	for range 10 {
+		if rec := get("bytes=0-8", ""); rec.Code == http.StatusPartialContent {
+			received += rec.Body.Len()
+		}
+	}
+	if received > 2*10 {
+		t.Fatalf("received %d bytes", received)
+	}
+	if rec := get("bytes=0-8", ""); rec.Code != http.StatusGone {
+		t.Fatalf("exhausted link served %d", rec.Code)
+	}
+}
+
+func TestLinkDownloadConcurrent(t *testing.T) {
+	_, _, get := newLinkDownloadTest(t, 1)
+
+	// 剩一次时同时下载，只有一个请求成功
+	const n = 10
+	codes := make([]int, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes[i] = get("", fmt.Sprintf("192.0.2.%d:1234", i+1)).Code
+		}()
+	}
+	wg.Wait()
+	var ok int
+	for _, code := range codes {
+		switch code {
+		case http.StatusOK:
+			ok++
+		case http.StatusGone:
+		default:
+			t.Fatalf("unexpected status %d", code)
+		}
+	}
+	if ok != 1 {
+		t.Fatalf("%d downloads succeeded", ok)
+	}
+}
+
+func TestLinkUploadConcurrentSameName(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "a.txt"), []byte("old"), 0644)
+	s := NewAppServer(dir)
+	s.Links = NewLinkRegistry()
+	h := s.Handler()
+	link := s.Links.Create(ShareLink{Kind: LinkRequest, Share: dir}, "")
+
+	// 所有上传都开始后再写入内容，各自保存为不同的文件，已有的文件不被覆盖
+	const n = 8
+	var wg sync.WaitGroup
+	for i := range n {
+		pr, pw := io.Pipe()
+		mw := multipart.NewWriter(pw)
+		req := httptest.NewRequest("POST", "/api/v1/links/"+link.Token+"/upload", pr)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			pr.Close()
+			if rec.Code != http.StatusCreated {
+				t.Errorf("upload %d: %d %s", i, rec.Code, rec.Body.String())
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			fw, _ := mw.CreateFormFile("file", "a.txt")
+			fw.Write([]byte("upload "))
+			time.Sleep(50 * time.Millisecond)
+			fmt.Fprint(fw, i)
+			mw.Close()
+			pw.Close()
+		}()
+	}
+	wg.Wait()
+
+	contents := make(map[string]bool)
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		data, _ := os.ReadFile(filepath.Join(dir, e.Name()))
+		contents[string(data)] = true
+	}
+	if !contents["old"] {
+		t.Fatal("existing file overwritten")
+	}
+	for i := range n {
+		if !contents[fmt.Sprintf("upload %d", i)] {
+			t.Errorf("upload %d lost", i)
+		}
+	}
+}
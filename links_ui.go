@@ -0,0 +1,366 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	fstorage "fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+	"github.com/skip2/go-qrcode"
+)
+
+// 新建链接时可选的有效期，0 表示永久有效
+var linkExpiryOptions = []struct {
+	id string
+	d  time.Duration
+}{
+	{"ui.link_expiry_hour", time.Hour},
+	{"ui.link_expiry_day", 24 * time.Hour},
+	{"ui.link_expiry_week", 7 * 24 * time.Hour},
+	{"ui.link_expiry_month", 30 * 24 * time.Hour},
+	{"ui.link_expiry_never", 0},
+}
+
+// 分享链接列表，可以新建、查看二维码、复制和撤销
+func createLinksPanel(window fyne.Window, state *AppState) fyne.CanvasObject {
+	var links []ShareLink
+	empty := widget.NewLabel(tr("ui.link_none"))
+
+	list := widget.NewList(
+		func() int {
+			return len(links)
+		},
+		func() fyne.CanvasObject {
+			name := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+			detail := widget.NewLabel("")
+			buttons := container.NewHBox(
+				widget.NewButton(tr("ui.link_qr"), nil),
+				widget.NewButton(tr("ui.link_copy"), nil),
+				widget.NewButton(tr("ui.link_revoke"), nil),
+			)
+			return container.NewBorder(nil, nil, nil, buttons, container.NewVBox(name, detail))
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			link := links[i]
+			row := o.(*fyne.Container)
+			info := row.Objects[0].(*fyne.Container)
+			buttons := row.Objects[1].(*fyne.Container)
+
+			info.Objects[0].(*widget.Label).SetText(linkTitle(link))
+			info.Objects[1].(*widget.Label).SetText(linkDetail(link, state))
+
+			buttons.Objects[0].(*widget.Button).OnTapped = func() {
+				showLinkQR(window, state, link)
+			}
+			buttons.Objects[1].(*widget.Button).OnTapped = func() {
+				fyne.CurrentApp().Clipboard().SetContent(linkURL(state, link))
+				showToast(tr("ui.link_copied"), window)
+			}
+			buttons.Objects[2].(*widget.Button).OnTapped = func() {
+				dialog.ShowConfirm(tr("ui.link_revoke"), tr("ui.link_revoke_confirm", linkTitle(link)), func(ok bool) {
+					if ok {
+						state.Links.Revoke(link.Token)
+					}
+				}, window)
+			}
+		},
+	)
+
+	refresh := func() {
+		links = state.Links.List()
+		empty.Hidden = len(links) > 0
+		empty.Refresh()
+		list.Refresh()
+	}
+	refresh()
+
+	// 定时刷新下载次数和过期状态
+	go func() {
+		for range time.Tick(2 * time.Second) {
+			fyne.Do(refresh)
+		}
+	}()
+
+	newBtn := widget.NewButton(tr("ui.link_new"), func() {
+		showNewLinkDialog(window, state, "", false)
+	})
+	requestBtn := widget.NewButton(tr("ui.request_new"), func() {
+		showNewRequestDialog(window, state, "")
+	})
+	return container.NewBorder(container.NewHBox(newBtn, requestBtn, empty), nil, nil, nil, list)
+}
+
+// 链接列表中显示的标题，临时分享显示拖入的文件名
+func linkTitle(link ShareLink) string {
+	if link.Kind == LinkTemp {
+		return tr("ui.temp_title", link.Name())
+	}
+	return "/" + link.Path
+}
+
+// 链接的状态、下载次数和有效期
+func linkDetail(link ShareLink, state *AppState) string {
+	uploadDir, _ := state.UploadDir.Get()
+	var status []string
+	switch err := link.check(time.Now()); {
+	case link.Kind != LinkTemp && link.Share != uploadDir:
+		status = append(status, tr("ui.link_other_share"))
+	case errors.Is(err, errLinkExpired):
+		status = append(status, tr("ui.link_expired"))
+	case errors.Is(err, errLinkExhausted):
+		status = append(status, tr("ui.link_exhausted"))
+	case errors.Is(err, errLinkFull):
+		status = append(status, tr("ui.request_full"))
+	case link.Expires.IsZero():
+		status = append(status, tr("ui.link_expiry_never"))
+	default:
+		status = append(status, tr("ui.link_until", link.Expires.Format("2006-01-02 15:04")))
+	}
+	if link.Protected() {
+		status = append(status, tr("ui.link_protected"))
+	}
+
+	if link.Kind == LinkRequest {
+		uploads := strconv.Itoa(link.Uploads)
+		if link.MaxFiles > 0 {
+			uploads += "/" + strconv.Itoa(link.MaxFiles)
+		}
+		return tr("ui.request_detail", linkURL(state, link), uploads, strings.Join(status, " · "))
+	}
+	downloads := strconv.Itoa(link.Downloads)
+	if link.MaxDownloads > 0 {
+		downloads += "/" + strconv.Itoa(link.MaxDownloads)
+	}
+	return tr("ui.link_detail", linkURL(state, link), downloads, strings.Join(status, " · "))
+}
+
+// 链接的访问地址
+func linkURL(state *AppState, link ShareLink) string {
+	server := state.Server
+	if server == nil {
+		// 尚未开始共享时按设置中的端口和地址生成
+		server = &AppServer{Port: state.Port, BindAddress: state.BindAddress}
+	}
+	return server.BaseURL() + linkPrefix + link.Token
+}
+
+// 新建分享链接，rel 为空时让用户选择共享文件夹内的文件或文件夹
+func showNewLinkDialog(window fyne.Window, state *AppState, rel string, isDir bool) {
+	uploadDir, _ := state.UploadDir.Get()
+	if uploadDir == "" {
+		showToast(tr("ui.folder_required"), window)
+		return
+	}
+
+	target := widget.NewLabel("/" + rel)
+	picked := rel != ""
+	targetRow := fyne.CanvasObject(target)
+	if !picked {
+		target.SetText("")
+		pick := func(p string, dir bool) {
+			rel, isDir, picked = p, dir, true
+			target.SetText("/" + rel)
+		}
+		fileBtn := widget.NewButton(tr("ui.link_file"), func() {
+			pickShareItem(window, uploadDir, false, func(p string) { pick(p, false) })
+		})
+		folderBtn := widget.NewButton(tr("ui.link_folder"), func() {
+			pickShareItem(window, uploadDir, true, func(p string) { pick(p, true) })
+		})
+		targetRow = container.NewBorder(nil, nil, nil, container.NewHBox(fileBtn, folderBtn), target)
+	}
+
+	expiry := newExpirySelect()
+	maxDownloads := newCountEntry()
+	password := widget.NewPasswordEntry()
+
+	dialog.ShowForm(tr("ui.link_new"), tr("ui.link_create"), tr("ui.cancel"), []*widget.FormItem{
+		widget.NewFormItem(tr("ui.link_target"), targetRow),
+		widget.NewFormItem(tr("ui.link_expiry"), expiry),
+		widget.NewFormItem(tr("ui.link_max_downloads"), maxDownloads),
+		widget.NewFormItem(tr("ui.link_password"), password),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		if !picked {
+			showToast(tr("ui.link_target_required"), window)
+			return
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(maxDownloads.Text))
+		link := state.Links.Create(ShareLink{
+			Share:        uploadDir,
+			Path:         rel,
+			IsDir:        isDir,
+			Expires:      expiryTime(expiry),
+			MaxDownloads: n,
+		}, password.Text)
+		showLinkQR(window, state, link)
+	}, window)
+}
+
+// 新建收集链接，访问者只能把文件上传到 rel 文件夹（可再指定一个子文件夹）
+func showNewRequestDialog(window fyne.Window, state *AppState, rel string) {
+	uploadDir, _ := state.UploadDir.Get()
+	if uploadDir == "" {
+		showToast(tr("ui.folder_required"), window)
+		return
+	}
+
+	target := widget.NewLabel("/" + rel)
+	folderBtn := widget.NewButton(tr("ui.request_choose_folder"), func() {
+		pickShareItem(window, uploadDir, true, func(p string) {
+			rel = p
+			target.SetText("/" + rel)
+		})
+	})
+	subfolder := widget.NewEntry()
+	subfolder.SetPlaceHolder(tr("ui.request_subfolder_hint"))
+
+	expiry := newExpirySelect()
+	maxFiles := newCountEntry()
+	maxSize := newCountEntry()
+	types := widget.NewEntry()
+	types.SetPlaceHolder(tr("ui.request_types_hint"))
+	password := widget.NewPasswordEntry()
+
+	dialog.ShowForm(tr("ui.request_new"), tr("ui.link_create"), tr("ui.cancel"), []*widget.FormItem{
+		widget.NewFormItem(tr("ui.request_folder"), container.NewBorder(nil, nil, nil, folderBtn, target)),
+		widget.NewFormItem(tr("ui.request_subfolder"), subfolder),
+		widget.NewFormItem(tr("ui.link_expiry"), expiry),
+		widget.NewFormItem(tr("ui.request_max_files"), maxFiles),
+		widget.NewFormItem(tr("ui.request_max_size"), maxSize),
+		widget.NewFormItem(tr("ui.request_types"), types),
+		widget.NewFormItem(tr("ui.link_password"), password),
+	}, func(ok bool) {
+		if !ok {
+			return
+		}
+		files, _ := strconv.Atoi(strings.TrimSpace(maxFiles.Text))
+		size, _ := strconv.ParseInt(strings.TrimSpace(maxSize.Text), 10, 64)
+		var allowed []string
+		for _, typ := range strings.FieldsFunc(types.Text, func(r rune) bool { return r == ',' || r == '，' || r == ' ' }) {
+			allowed = append(allowed, strings.ToLower(typ))
+		}
+		link := state.Links.Create(ShareLink{
+			Kind:         LinkRequest,
+			Share:        uploadDir,
+			Path:         cleanRelPath(rel + "/" + strings.TrimSpace(subfolder.Text)),
+			Expires:      expiryTime(expiry),
+			MaxFiles:     files,
+			MaxFileSize:  size << 20,
+			AllowedTypes: allowed,
+		}, password.Text)
+		showLinkQR(window, state, link)
+	}, window)
+}
+
+// 选择共享文件夹内的文件或文件夹，返回相对共享文件夹的路径
+func pickShareItem(window fyne.Window, uploadDir string, dir bool, onPick func(rel string)) {
+	pick := func(p string) {
+		r, err := filepath.Rel(uploadDir, p)
+		if err != nil || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) || filepath.IsAbs(r) {
+			dialog.ShowError(errors.New(tr("ui.link_outside_share")), window)
+			return
+		}
+		onPick(cleanRelPath(filepath.ToSlash(r)))
+	}
+	location, _ := fstorage.ListerForURI(fstorage.NewFileURI(uploadDir))
+	if dir {
+		d := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err == nil && uri != nil {
+				pick(uri.Path())
+			}
+		}, window)
+		d.SetLocation(location)
+		d.Show()
+		return
+	}
+	d := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err == nil && reader != nil {
+			reader.Close()
+			pick(reader.URI().Path())
+		}
+	}, window)
+	d.SetLocation(location)
+	d.Show()
+}
+
+// 有效期选择，默认 1 天
+func newExpirySelect() *widget.Select {
+	var names []string
+	for _, o := range linkExpiryOptions {
+		names = append(names, tr(o.id))
+	}
+	sel := widget.NewSelect(names, nil)
+	sel.SetSelectedIndex(1)
+	return sel
+}
+
+// 按选择的有效期计算过期时间，永久有效时为零
+func expiryTime(sel *widget.Select) time.Time {
+	if d := linkExpiryOptions[sel.SelectedIndex()].d; d > 0 {
+		return time.Now().Add(d)
+	}
+	return time.Time{}
+}
+
+// 输入不小于 0 的整数，0 表示不限
+func newCountEntry() *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetText("0")
+	entry.Validator = func(s string) error {
+		if n, err := strconv.Atoi(strings.TrimSpace(s)); err != nil || n < 0 {
+			return errors.New(tr("ui.rate_invalid"))
+		}
+		return nil
+	}
+	return entry
+}
+
+// 显示链接的地址和二维码
+func showLinkQR(window fyne.Window, state *AppState, link ShareLink) {
+	url := linkURL(state, link)
+	img, err := newQRImage(url, 256)
+	if err != nil {
+		dialog.ShowError(err, window)
+		return
+	}
+	address := widget.NewLabel(url)
+	address.Selectable = true
+	address.Alignment = fyne.TextAlignCenter
+	content := container.NewVBox(img, address)
+	if running, _ := state.ServerRunning.Get(); !running {
+		content.Add(widget.NewLabel(tr("ui.link_not_sharing")))
+	}
+	copyBtn := widget.NewButton(tr("ui.link_copy"), func() {
+		fyne.CurrentApp().Clipboard().SetContent(url)
+		showToast(tr("ui.link_copied"), window)
+	})
+	content.Add(container.NewCenter(copyBtn))
+	dialog.ShowCustom(linkTitle(link), tr("ui.close"), content, window)
+}
+
+// 生成指定地址的二维码图片
+func newQRImage(text string, size int) (*canvas.Image, error) {
+	qr, err := qrcode.New(text, qrcode.Medium)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, qr.Image(size)); err != nil {
+		return nil, err
+	}
+	img := canvas.NewImageFromResource(fyne.NewStaticResource("qrcode.png", buf.Bytes()))
+	img.FillMode = canvas.ImageFillOriginal
+	return img, nil
+}
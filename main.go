@@ -1,16 +1,20 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"image/color"
-	"image/png"
 	"log"
+	"maps"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strconv"
+	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
@@ -18,21 +22,51 @@ import (
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/data/binding"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/lang"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
-	"github.com/skip2/go-qrcode"
 )
 
 // 应用状态
 type AppState struct {
 	UploadDir     binding.String
 	ServerAddress binding.String
+	DAVAddress    binding.String
 	ServerRunning binding.Bool
 	StatusMessage binding.String
 	Uploads       binding.UntypedList
 	TotalUploads  binding.Int
 	TotalSize     binding.String
 	CurrentSpeed  binding.String
+	Limits        UploadLimits
+	Storage       StorageConfig
+	CORS          CORSConfig
+	Throttle      *Throttle
+	Devices       *DeviceRegistry
+	Links         *LinkRegistry
+	Notifier      *uploadNotifier
+	Discovery     *Discovery
+	Audit         *AuditLog
+	AuditConfig   AuditConfig
+	Metrics       MetricsConfig
 	Server        *AppServer
+
+	OnFilesChanged func() // 共享文件夹中的文件变化时在界面线程调用，刷新文件列表
+
+	Port            int        // 监听的端口，重新开始共享后生效
+	BindAddress     string     // 监听的地址，为空时监听所有网卡
+	Auth            AuthConfig // 访问共享需要的账号密码
+	RequireApproval bool       // 接收上传前需要桌面端确认
+	RecentFolders   []string   // 最近共享的文件夹
+	FavoriteFolders []string   // 收藏的文件夹
+	AutoStart       bool       // 启动时恢复上次退出时的共享
+	WasSharing      bool       // 是否正在共享，退出时保留
+	MinimizeToTray  bool       // 关闭窗口时隐藏到系统托盘，继续共享
+	MutedShares     []string   // 收到文件时不通知的共享文件夹
+	FollowSymlinks  bool       // 跟随共享文件夹内的符号链接
+	Mode            ShareMode  // 共享模式
+	Language        string     // 界面语言 zh 或 en，为空时跟随系统
 }
 
 type MyApp struct {
@@ -42,19 +76,23 @@ func main() {
 	// 创建应用
 	a := app.New()
 	// a.Settings().SetTheme(&customTheme{})
-	w := a.NewWindow("快传-局域网文件共享")
-	w.SetMaster()
 
 	// 创建状态
 	state := &AppState{
 		UploadDir:     binding.NewString(),
 		ServerAddress: binding.NewString(),
+		DAVAddress:    binding.NewString(),
 		ServerRunning: binding.NewBool(),
 		StatusMessage: binding.NewString(),
 		Uploads:       binding.NewUntypedList(),
 		TotalUploads:  binding.NewInt(),
 		TotalSize:     binding.NewString(),
 		CurrentSpeed:  binding.NewString(),
+		Throttle:      NewThrottle(ThrottleRates{}),
+		Devices:       NewDeviceRegistry(),
+		Links:         NewLinkRegistry(),
+		Notifier:      &uploadNotifier{},
+		Discovery:     NewDiscovery(hostName(), defaultPort),
 	}
 	// 设置默认上传目录
 	// defaultDir := filepath.Join(os.Getenv("HOME"), "Uploads")
@@ -63,15 +101,40 @@ func main() {
 	// }
 	// state.UploadDir.Set(defaultDir)
 
-	// 加载配置
-	loadConfig(state)
+	// 加载配置，同时确定界面语言
+	configErr := loadConfig(state)
+	state.Devices.OnSave = func() { saveConfig(state) }
+	state.Links.OnSave = func() { saveConfig(state) }
+
+	// 打开访问记录，与配置文件放在一起
+	if !state.AuditConfig.Disabled {
+		audit, err := OpenAuditLog(filepath.Join(filepath.Dir(configFilePath()), "audit.log"), state.AuditConfig)
+		if err != nil {
+			log.Printf("打开访问记录失败: %v", err)
+		} else {
+			state.Audit = audit
+		}
+	}
+
+	w := a.NewWindow(tr("ui.window_title"))
+	w.SetMaster()
+
+	// 发现局域网中的其他快传
+	state.Discovery.Start()
 
 	// 创建UI
 	content := createUI(w, state)
 	w.SetContent(content)
+	if configErr != nil {
+		dialog.ShowError(configErr, w)
+	}
 
-	// 窗口关闭时保存配置
-	w.SetOnClosed(state.Server.StopServer)
+	// 窗口关闭时保存访问记录
+	w.SetOnClosed(func() {
+		if state.Audit != nil {
+			state.Audit.Close()
+		}
+	})
 
 	// 显示窗口
 	w.Resize(fyne.NewSize(800, 600))
@@ -103,7 +166,7 @@ func main() {
 func showToast(message string, win fyne.Window) {
 	// 创建无按钮的自定义对话框
 	toastContent := widget.NewLabel("")
-	customDialog := dialog.NewCustom(message, "知道了", toastContent, win)
+	customDialog := dialog.NewCustom(message, tr("ui.got_it"), toastContent, win)
 
 	// 显示并自动关闭
 	customDialog.Show()
@@ -112,7 +175,11 @@ func createUI(window fyne.Window, state *AppState) fyne.CanvasObject {
 
 	savePathLabel := widget.NewLabelWithData(state.UploadDir)
 
-	selectDirBtn := widget.NewButton("选择共享文件夹", func() {
+	// 停止共享，完成后调用 then；切换共享文件夹。在下面创建按钮后赋值
+	var stop func(then func())
+	var switchFolder func(dir string)
+
+	selectDirBtn := widget.NewButton(tr("ui.select_folder"), func() {
 		// dialog.showfile
 		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
 			if err != nil {
@@ -121,17 +188,14 @@ func createUI(window fyne.Window, state *AppState) fyne.CanvasObject {
 			}
 
 			if uri != nil {
-				state.UploadDir.Set(uri.Path())
-				saveConfig(state)
-				state.StatusMessage.Set("上传目录已更新")
-				state.Server.StopServer()
+				switchFolder(uri.Path())
 			}
 		}, window)
 	})
-	openBtn := widget.NewButton("打开", func() {
+	openBtn := widget.NewButton(tr("ui.open"), func() {
 		uploadDir, _ := state.UploadDir.Get()
 		if len(uploadDir) == 0 {
-			showToast("请选择共享文件夹", window)
+			showToast(tr("ui.folder_required"), window)
 			return
 		}
 		openFolder(uploadDir)
@@ -141,69 +205,180 @@ func createUI(window fyne.Window, state *AppState) fyne.CanvasObject {
 	// addressLabel.TextStyle = fyne.TextStyle{Bold: true}
 
 	// 生成二维码
-	qr, err := qrcode.New("http://"+state.Server.GetLocalIP()+":8000", qrcode.Medium)
-	if err != nil {
-		panic(err)
-	}
-
-	// 将二维码转为PNG
-	var buf bytes.Buffer
-	err = png.Encode(&buf, qr.Image(256))
-	if err != nil {
-		panic(err)
-	}
-
-	// 创建Fyne资源
-	resource := fyne.NewStaticResource("qrcode.png", buf.Bytes())
-
-	// 更新图片
-	qrImage := canvas.NewImageFromResource(resource)
+	// 二维码在开始共享时按实际地址生成
+	qrImage := canvas.NewImageFromResource(nil)
 	qrImage.FillMode = canvas.ImageFillOriginal
 	qrImage.Hide()
 	// 服务器控制按钮
-	serverBtn := widget.NewButton("开始共享", nil)
+	serverBtn := widget.NewButton(tr("ui.start_sharing"), nil)
 	c := canvas.NewText("", color.NRGBA{R: 255, G: 128, B: 0, A: 255})
 	c.TextStyle = fyne.TextStyle{Bold: true}
 	c.Alignment = fyne.TextAlignCenter
-	n := container.NewHBox(
-		widget.NewLabel("浏览器访问:"),
-		addressLabel,
+	n := container.NewVBox(
+		container.NewHBox(widget.NewLabel(tr("ui.browser_address")), addressLabel),
+		container.NewHBox(widget.NewLabel(tr("ui.dav_address")), widget.NewLabelWithData(state.DAVAddress)),
 	)
 	n.Hide()
 
 	serverBtn.OnTapped = func() {
 		uploadDir, _ := state.UploadDir.Get()
 		if len(uploadDir) == 0 {
-			showToast("请选择共享文件夹", window)
+			showToast(tr("ui.folder_required"), window)
 			return
 		}
 		serverRunning, _ := state.ServerRunning.Get()
 		if serverRunning {
-			state.Server.StopServer()
-			state.ServerRunning.Set(false)
-			serverBtn.SetText("开始共享")
-			c.Text = ""
-			qrImage.Hide()
-			n.Hide()
-			// state.StatusMessage.Set("服务器已停止")
+			stop(func() {
+				state.WasSharing = false
+				saveConfig(state)
+			})
 		} else {
 
+			// 打开共享文件夹的存储
+			storage, err := state.Storage.Open(uploadDir, state.FollowSymlinks)
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("%s: %w", tr("ui.open_storage_failed"), err), window)
+				return
+			}
+
 			// 启动服务器
 			state.Server = NewAppServer(uploadDir)
-			go state.Server.StartServer()
+			state.Server.Port = state.Port
+			state.Server.BindAddress = state.BindAddress
+			state.Server.Auth = state.Auth
+			state.Server.FollowSymlinks = state.FollowSymlinks
+			state.Server.Storage = storage
+			state.Server.Limits = state.Limits
+			state.Server.Throttle = state.Throttle
+			state.Server.Devices = state.Devices
+			state.Server.Links = state.Links
+			state.Server.CORS = state.CORS
+			state.Server.Audit = state.Audit
+			state.Server.Metrics = state.Metrics
+			state.Server.SetRequireApproval(state.RequireApproval)
+			state.Server.SetMode(state.Mode)
+			state.Server.OnApprovalRequest = func(ctx context.Context, req UploadRequest, decide func(bool)) {
+				fyne.Do(func() {
+					showApprovalDialog(ctx, window, req, decide)
+				})
+			}
+			// 收到文件时通知，文件不在本地时无法在文件管理器中显示
+			localDir := ""
+			if state.Storage.Type == "" || state.Storage.Type == "local" {
+				localDir = uploadDir
+			}
+			state.Notifier.SetShare(localDir)
+			state.Notifier.SetMuted(slices.Contains(state.MutedShares, uploadDir))
+			state.Server.OnFileReceived = func(file FileInfo, device string) {
+				state.Notifier.Add(file, device)
+				if state.OnFilesChanged != nil {
+					fyne.Do(state.OnFilesChanged)
+				}
+			}
+			if err := state.Server.StartServer(); err != nil {
+				storage.Close()
+				dialog.ShowError(fmt.Errorf("%s: %w", tr("ui.start_failed"), err), window)
+				return
+			}
+			state.Discovery.SetPort(state.Server.port())
+			state.WasSharing = true
+			state.RecentFolders = addRecentFolder(state.RecentFolders, uploadDir)
+			saveConfig(state)
+			state.Discovery.SetAnnouncing(true)
 			state.ServerRunning.Set(true)
-			serverBtn.SetText("停止共享")
+			serverBtn.SetText(tr("ui.stop_sharing"))
 
 			// 	// 更新服务器地址显示
-			ip := state.Server.GetLocalIP()
-			state.ServerAddress.Set(fmt.Sprintf("http://%s:8000", ip))
+			address := state.Server.BaseURL()
+			state.ServerAddress.Set(address)
+			state.DAVAddress.Set(address + davPrefix + "/")
+			if img, err := newQRImage(address, 256); err == nil {
+				qrImage.Resource = img.Resource
+				qrImage.Refresh()
+			}
 			// state.StatusMessage.Set("服务器已启动")
-			c.Text = "正在共享"
+			c.Text = tr("ui.sharing")
 			qrImage.Show()
 			n.Show()
 
 		}
 	}
+	stop = func(then func()) {
+		serverBtn.Disable()
+		stopSharing(window, state.Server, func(stopped bool) {
+			serverBtn.Enable()
+			if !stopped {
+				return
+			}
+			state.Discovery.SetAnnouncing(false)
+			state.ServerRunning.Set(false)
+			serverBtn.SetText(tr("ui.start_sharing"))
+			c.Text = ""
+			c.Refresh()
+			qrImage.Hide()
+			n.Hide()
+			then()
+		})
+	}
+
+	// 切换共享文件夹，正在共享时用新的文件夹重新开始
+	switchFolder = func(dir string) {
+		current, _ := state.UploadDir.Get()
+		state.UploadDir.Set(dir)
+		state.RecentFolders = addRecentFolder(state.RecentFolders, dir)
+		saveConfig(state)
+		if dir == current {
+			return
+		}
+		state.StatusMessage.Set(tr("ui.folder_updated"))
+		if running, _ := state.ServerRunning.Get(); running {
+			stop(serverBtn.OnTapped)
+		}
+	}
+	foldersBtn := newFoldersButton(window, state, func(dir string) { switchFolder(dir) })
+
+	// 退出前先停止共享
+	quit := func() {
+		if running, _ := state.ServerRunning.Get(); running {
+			if len(state.Server.ActiveTransfers()) > 0 {
+				window.Show()
+			}
+			stop(window.Close)
+			return
+		}
+		window.Close()
+	}
+
+	// 系统托盘，窗口隐藏后仍可控制共享
+	trayOK := setupTray(window, state, trayActions{
+		ToggleSharing: func() {
+			// 需要提示或选择时先显示窗口
+			uploadDir, _ := state.UploadDir.Get()
+			running, _ := state.ServerRunning.Get()
+			if uploadDir == "" || running && len(state.Server.ActiveTransfers()) > 0 {
+				window.Show()
+			}
+			serverBtn.OnTapped()
+		},
+		Quit: quit,
+	})
+
+	// 拖入文件时选择复制到共享文件夹或临时分享
+	setupDrop(window, state)
+
+	// 开启托盘模式时关闭窗口只是隐藏，第一次隐藏时提示仍在后台运行
+	hiddenNotified := false
+	window.SetCloseIntercept(func() {
+		if !state.MinimizeToTray || !trayOK {
+			quit()
+			return
+		}
+		window.Hide()
+		if !hiddenNotified {
+			hiddenNotified = true
+			fyne.CurrentApp().SendNotification(fyne.NewNotification(tr("ui.window_title"), tr("ui.tray_hidden")))
+		}
+	})
 	// // 统计信息
 	// statsPanel := container.NewGridWithColumns(3,
 	// 	// createStatCard("今日上传", state.TotalUploads.StringWithFormat("%d 个文件")),
@@ -249,36 +424,263 @@ func createUI(window fyne.Window, state *AppState) fyne.CanvasObject {
 	// // },
 	// )
 
+	// 发送到其他电脑
+	sendBtn := widget.NewButton(tr("ui.send_to_other"), func() {
+		showSendDialog(window, state)
+	})
+
+	// 收到文件时通知，每个共享文件夹分别设置
+	notifyCheck := widget.NewCheck(tr("ui.notify_uploads"), func(checked bool) {
+		uploadDir, _ := state.UploadDir.Get()
+		muted := slices.Contains(state.MutedShares, uploadDir)
+		if muted != checked {
+			return
+		}
+		if checked {
+			state.MutedShares = slices.DeleteFunc(state.MutedShares, func(s string) bool { return s == uploadDir })
+		} else {
+			state.MutedShares = append(state.MutedShares, uploadDir)
+		}
+		state.Notifier.SetMuted(!checked)
+		saveConfig(state)
+	})
+	state.UploadDir.AddListener(binding.NewDataListener(func() {
+		uploadDir, _ := state.UploadDir.Get()
+		notifyCheck.SetChecked(!slices.Contains(state.MutedShares, uploadDir))
+	}))
+
+	// 设置
+	settingsBtn := widget.NewButton(tr("ui.settings"), func() {
+		showSettingsDialog(window, state, trayOK)
+	})
+
 	container.NewPadded()
 
+	// 已连接设备
+	tabs := container.NewAppTabs(
+		container.NewTabItem(tr("ui.tab_files"), createFilesPanel(window, state)),
+		container.NewTabItem(tr("ui.tab_devices"), createDevicesPanel(window, state)),
+		container.NewTabItem(tr("ui.tab_links"), createLinksPanel(window, state)),
+		container.NewTabItem(tr("ui.tab_audit"), createAuditPanel(window, state)),
+	)
+
 	// 主布局
 	mainLayout := container.NewBorder(
 		container.NewVBox(
 			container.NewPadded(),
 			container.NewHBox(
-				widget.NewLabel("共享文件夹:"),
+				widget.NewLabel(tr("ui.shared_folder")),
 				savePathLabel,
 				selectDirBtn,
+				foldersBtn,
 				openBtn,
 			),
+			container.NewHBox(notifyCheck, layout.NewSpacer(), settingsBtn),
 			container.NewPadded(),
-			serverBtn,
+			container.NewGridWithColumns(2, serverBtn, sendBtn),
 
 			container.NewCenter(n),
 			qrImage,
 			c,
+			widget.NewAccordion(widget.NewAccordionItem(tr("ui.throttle"), createThrottleForm(window, state))),
 		),
 		nil,
 		nil,
 		nil,
+		tabs,
 		// container.NewVBox(
 		// 	widget.NewLabel("上传历史"),
 		// 	statsPanel,
 		// ),
 	)
 
+	// 上次退出时正在共享，开启了自动开始时恢复共享
+	if uploadDir, _ := state.UploadDir.Get(); state.AutoStart && state.WasSharing && uploadDir != "" {
+		serverBtn.OnTapped()
+	}
+
 	return mainLayout
 }
+
+// 弹窗询问是否接收设备的上传，超时后自动关闭
+func showApprovalDialog(ctx context.Context, window fyne.Window, req UploadRequest, decide func(bool)) {
+	var total int64
+	for _, f := range req.Files {
+		total += f.Size
+	}
+
+	content := container.NewVBox(
+		widget.NewLabel(tr("ui.approval_request", req.Device, req.IP, len(req.Files), formatFileSize(total))),
+		widget.NewLabelWithStyle(req.Agent, fyne.TextAlignLeading, fyne.TextStyle{Italic: true}),
+	)
+	const maxShown = 10
+	for i, f := range req.Files {
+		if i == maxShown {
+			content.Add(widget.NewLabel(tr("ui.approval_more", len(req.Files))))
+			break
+		}
+		content.Add(widget.NewLabel(fmt.Sprintf("%s  (%s)", f.Name, formatFileSize(f.Size))))
+	}
+
+	d := dialog.NewCustomConfirm(tr("ui.approval_title"), tr("ui.accept"), tr("ui.decline"), content, decide, window)
+	d.Show()
+	go func() {
+		<-ctx.Done()
+		fyne.Do(d.Hide)
+	}()
+}
+
+// 限速设置表单，修改后立即对正在进行的传输生效
+func createThrottleForm(window fyne.Window, state *AppState) fyne.CanvasObject {
+	rates := state.Throttle.Rates()
+
+	uploadEntry := newRateEntry(rates.Upload)
+	downloadEntry := newRateEntry(rates.Download)
+	clientUploadEntry := newRateEntry(rates.ClientUpload)
+	clientDownloadEntry := newRateEntry(rates.ClientDownload)
+
+	form := widget.NewForm(
+		widget.NewFormItem(tr("ui.rate_upload"), uploadEntry),
+		widget.NewFormItem(tr("ui.rate_download"), downloadEntry),
+		widget.NewFormItem(tr("ui.rate_client_upload"), clientUploadEntry),
+		widget.NewFormItem(tr("ui.rate_client_download"), clientDownloadEntry),
+	)
+	form.SubmitText = tr("ui.apply")
+	form.OnSubmit = func() {
+		rates := state.Throttle.Rates()
+		rates.Upload = parseRateEntry(uploadEntry)
+		rates.Download = parseRateEntry(downloadEntry)
+		rates.ClientUpload = parseRateEntry(clientUploadEntry)
+		rates.ClientDownload = parseRateEntry(clientDownloadEntry)
+		state.Throttle.SetRates(rates)
+		saveConfig(state)
+		showToast(tr("ui.throttle_applied"), window)
+	}
+
+	// 按IP单独设置的速度，增删改后立即生效
+	clientList := container.NewVBox()
+	var refreshClients func()
+	setClientRate := func(oldIP, ip string, rate *ClientRate) {
+		rates := state.Throttle.Rates()
+		// 复制后修改，正在使用的配置可能同时被读取
+		clients := maps.Clone(rates.Clients)
+		if clients == nil {
+			clients = make(map[string]ClientRate)
+		}
+		delete(clients, oldIP)
+		if rate != nil {
+			clients[ip] = *rate
+		}
+		rates.Clients = clients
+		state.Throttle.SetRates(rates)
+		saveConfig(state)
+		refreshClients()
+		showToast(tr("ui.throttle_applied"), window)
+	}
+	refreshClients = func() {
+		clients := state.Throttle.Rates().Clients
+		clientList.RemoveAll()
+		if len(clients) == 0 {
+			clientList.Add(widget.NewLabel(tr("ui.rate_clients_empty")))
+		}
+		for _, ip := range slices.Sorted(maps.Keys(clients)) {
+			rate := clients[ip]
+			editBtn := widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), func() {
+				showClientRateDialog(window, state, ip, rate, func(newIP string, rate ClientRate) {
+					setClientRate(ip, newIP, &rate)
+				})
+			})
+			removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+				setClientRate(ip, "", nil)
+			})
+			label := widget.NewLabel(tr("ui.rate_client_summary", ip, formatRate(rate.Upload), formatRate(rate.Download)))
+			clientList.Add(container.NewBorder(nil, nil, nil, container.NewHBox(editBtn, removeBtn), label))
+		}
+	}
+	refreshClients()
+	addBtn := widget.NewButtonWithIcon(tr("ui.rate_client_add"), theme.ContentAddIcon(), func() {
+		showClientRateDialog(window, state, "", ClientRate{}, func(ip string, rate ClientRate) {
+			setClientRate("", ip, &rate)
+		})
+	})
+
+	return container.NewVBox(
+		form,
+		widget.NewSeparator(),
+		container.NewBorder(nil, nil, nil, addBtn, widget.NewLabelWithStyle(tr("ui.rate_clients"), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})),
+		clientList,
+	)
+}
+
+// 限速输入框，单位为 KB/s
+func newRateEntry(rate int64) *widget.Entry {
+	entry := widget.NewEntry()
+	entry.SetText(strconv.FormatInt(rate/1024, 10))
+	entry.Validator = func(s string) error {
+		if v, err := strconv.ParseInt(s, 10, 64); err != nil || v < 0 {
+			return errors.New(tr("ui.rate_invalid"))
+		}
+		return nil
+	}
+	return entry
+}
+
+func parseRateEntry(e *widget.Entry) int64 {
+	v, _ := strconv.ParseInt(e.Text, 10, 64)
+	return v * 1024
+}
+
+// 显示速度，0 表示不限速
+func formatRate(rate int64) string {
+	if rate <= 0 {
+		return tr("ui.rate_unlimited")
+	}
+	return fmt.Sprintf("%d KB/s", rate/1024)
+}
+
+// 添加或修改一台设备的限速，IP 可从已连接的设备中选择
+func showClientRateDialog(window fyne.Window, state *AppState, ip string, rate ClientRate, onSave func(ip string, rate ClientRate)) {
+	var ips []string
+	for _, d := range state.Devices.List() {
+		if !slices.Contains(ips, d.IP) {
+			ips = append(ips, d.IP)
+		}
+	}
+	ipEntry := widget.NewSelectEntry(ips)
+	ipEntry.SetText(ip)
+	ipEntry.Validator = func(s string) error {
+		if net.ParseIP(strings.TrimSpace(s)) == nil {
+			return errors.New(tr("ui.rate_client_ip_invalid"))
+		}
+		return nil
+	}
+	uploadEntry := newRateEntry(rate.Upload)
+	downloadEntry := newRateEntry(rate.Download)
+	items := []*widget.FormItem{
+		widget.NewFormItem(tr("ui.rate_client_ip"), ipEntry),
+		widget.NewFormItem(tr("ui.rate_single_upload"), uploadEntry),
+		widget.NewFormItem(tr("ui.rate_single_download"), downloadEntry),
+	}
+	d := dialog.NewForm(tr("ui.rate_client_title"), tr("ui.apply"), tr("ui.cancel"), items, func(ok bool) {
+		if ok {
+			onSave(strings.TrimSpace(ipEntry.Text), ClientRate{Upload: parseRateEntry(uploadEntry), Download: parseRateEntry(downloadEntry)})
+		}
+	}, window)
+	d.Resize(fyne.NewSize(400, 0))
+	d.Show()
+}
+
+// 桌面端语言：优先使用设置，未设置时跟随系统语言
+func desktopLang(setting string) Lang {
+	if l, ok := parseLang(setting); ok {
+		return l
+	}
+	if l, ok := parseLang(string(lang.SystemLocale())); ok {
+		return l
+	}
+	return defaultLang
+}
+
 func openFolder(dir string) {
 	// 根据操作系统选择不同的命令打开文件夹
 	var cmd *exec.Cmd
@@ -626,64 +1028,92 @@ func configFilePath() string {
 	return filepath.Join(configDir, "file-upload-server", "config.json")
 }
 
-func loadConfig(state *AppState) {
+// 读取配置文件并应用到状态，返回需要提示用户的错误
+func loadConfig(state *AppState) error {
 	configPath := configFilePath()
-	print(configPath)
-
-	// 检查配置文件是否存在
-	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		return
-	}
-
-	// 读取配置文件
-	data, err := os.ReadFile(configPath)
+	config, err := LoadConfig(configPath)
 	if err != nil {
+		// 保留无法读取的配置文件，避免被默认配置覆盖
 		log.Printf("读取配置文件失败: %v", err)
-		return
+		if renameErr := os.Rename(configPath, configPath+".bak"); renameErr == nil {
+			err = errors.New(tr("ui.config_unreadable", err, configPath+".bak"))
+		}
 	}
 
-	// 解析JSON
-	var config map[string]interface{}
-	if err := json.Unmarshal(data, &config); err != nil {
-		log.Printf("解析配置文件失败: %v", err)
-		return
+	// 确定界面语言后再检查，提示使用界面语言
+	uiLang = desktopLang(config.UI.Language)
+	if repairErr := config.Repair(); repairErr != nil {
+		log.Printf("配置有误: %v", repairErr)
+		err = errors.Join(err, errors.New(tr("ui.config_repaired")), repairErr)
 	}
 
-	// 应用配置
-	if uploadDir, ok := config["uploadDir"].(string); ok {
-		state.UploadDir.Set(uploadDir)
-	}
+	state.applyConfig(config)
+	state.Throttle.SetRates(config.Throttle)
+	state.Devices.SetKey(config.DeviceKey)
+	state.Devices.Load(config.Devices)
+	state.Links.Load(config.Links)
+	return err
 }
 
-func saveConfig(state *AppState) {
-	configPath := configFilePath()
-
-	// 创建配置目录
-	configDir := filepath.Dir(configPath)
-	if _, err := os.Stat(configDir); os.IsNotExist(err) {
-		if err := os.MkdirAll(configDir, 0755); err != nil {
-			log.Printf("创建配置目录失败: %v", err)
-			return
-		}
-	}
+// 应用配置中的设置，设备和分享链接只在启动时加载
+func (state *AppState) applyConfig(config Config) {
+	state.UploadDir.Set(config.UploadDir)
+	state.RecentFolders = config.RecentFolders
+	state.FavoriteFolders = config.FavoriteFolders
+	state.AutoStart = config.AutoStart
+	state.WasSharing = config.WasSharing
+	state.Port = config.Port
+	state.BindAddress = config.BindAddress
+	state.Mode = config.Mode
+	state.RequireApproval = config.RequireApproval
+	state.FollowSymlinks = config.FollowSymlinks
+	state.Auth = config.Auth
+	state.Limits = config.Limits
+	state.Storage = config.Storage
+	state.CORS = config.CORS
+	state.AuditConfig = config.Audit
+	state.Metrics = config.Metrics
+	state.Language = config.UI.Language
+	state.MinimizeToTray = config.UI.MinimizeToTray
+	state.MutedShares = config.UI.MutedShares
+}
 
-	// 获取当前配置
+// 当前状态对应的配置
+func (state *AppState) config() Config {
 	uploadDir, _ := state.UploadDir.Get()
-
-	// 保存配置
-	config := map[string]interface{}{
-		"uploadDir": uploadDir,
-	}
-
-	data, err := json.Marshal(config)
-	if err != nil {
-		log.Printf("序列化配置失败: %v", err)
-		return
+	return Config{
+		Version:         configVersion,
+		UploadDir:       uploadDir,
+		RecentFolders:   state.RecentFolders,
+		FavoriteFolders: state.FavoriteFolders,
+		AutoStart:       state.AutoStart,
+		WasSharing:      state.WasSharing,
+		Port:            state.Port,
+		BindAddress:     state.BindAddress,
+		Mode:            state.Mode,
+		RequireApproval: state.RequireApproval,
+		FollowSymlinks:  state.FollowSymlinks,
+		Auth:            state.Auth,
+		Limits:          state.Limits,
+		Throttle:        state.Throttle.Rates(),
+		Storage:         state.Storage,
+		CORS:            state.CORS,
+		Audit:           state.AuditConfig,
+		Metrics:         state.Metrics,
+		UI: UIConfig{
+			Language:       state.Language,
+			MinimizeToTray: state.MinimizeToTray,
+			MutedShares:    state.MutedShares,
+		},
+		Devices:   state.Devices.Saved(),
+		DeviceKey: state.Devices.Key(),
+		Links:     state.Links.Saved(),
 	}
+}
 
-	if err := os.WriteFile(configPath, data, 0644); err != nil {
+func saveConfig(state *AppState) {
+	if err := state.config().Save(configFilePath()); err != nil {
 		log.Printf("保存配置文件失败: %v", err)
-		return
 	}
 }
 
@@ -0,0 +1,361 @@
+package main
+
+// 英文文本，键与 messagesZH 一致
+var messagesEN = map[string]string{
+	// API 错误，消息ID即错误码
+	"not_found":               "No such endpoint",
+	"method_not_allowed":      "Method not allowed",
+	"bad_request":             "Malformed request",
+	"missing_file":            "No file in the request",
+	"missing_filename":        "Missing file name",
+	"file_not_found":          "File not found",
+	"dir_not_found":           "Folder not found",
+	"storage_unavailable":     "The shared folder is unavailable",
+	"upload_forbidden_path":   "Uploading to this location is not allowed",
+	"upload_failed":           "Upload failed: %v",
+	"upload_target_is_dir":    "A folder with this name already exists",
+	"upload_denied":           "The upload request was declined",
+	"upload_approval_timeout": "Timed out waiting for approval",
+	"device_blocked":          "This device has been blocked",
+	"device_name_too_long":    "The name must be at most 32 characters",
+	"search_missing_query":    "Missing search keyword",
+	"share_read_only":         "The share is read-only",
+	"share_upload_only":       "The share is upload-only",
+	"auth_required":           "Log in to access this share",
+	"limit_file_size":         "The file exceeds the size limit (max %s)",
+	"limit_client_quota":      "This device has exceeded its upload quota (%s)",
+	"limit_share_quota":       "The shared folder has exceeded its quota (%s)",
+	"limit_disk_full":         "Not enough disk space",
+	"server_stopping":         "The share is stopping",
+	"link_not_found":          "The share link does not exist or has been revoked",
+	"link_expired":            "The share link has expired",
+	"link_exhausted":          "The share link has reached its download limit",
+	"link_password_required":  "A password is required for this share link",
+	"link_password_wrong":     "Wrong password",
+	"link_full":               "This request is no longer accepting files",
+	"link_type_not_allowed":   "This file type is not accepted, allowed: %s",
+
+	// 接口的成功提示
+	"msg.upload_ok":       "File uploaded",
+	"msg.upload_approved": "The upload request was accepted",
+
+	// 桌面端
+	"ui.window_title":             "Kuaichuan - LAN File Sharing",
+	"ui.got_it":                   "OK",
+	"ui.select_folder":            "Choose shared folder",
+	"ui.folder_updated":           "Shared folder updated",
+	"ui.folders":                  "Recent",
+	"ui.folder_favorite":          "Add current folder to favorites",
+	"ui.folder_unfavorite":        "Remove current folder from favorites",
+	"ui.folder_clear_recent":      "Clear recent folders",
+	"ui.folder_none":              "No recent or favorite folders yet",
+	"ui.folder_missing":           "Folder not found: %s",
+	"ui.open":                     "Open",
+	"ui.folder_required":          "Please choose a shared folder",
+	"ui.start_sharing":            "Start sharing",
+	"ui.stop_sharing":             "Stop sharing",
+	"ui.stop_title":               "Stop sharing",
+	"ui.stop_active":              "%d transfers are in progress and will be cut off",
+	"ui.stop_wait":                "Wait for them (up to %d s)",
+	"ui.stop_force":               "Stop now",
+	"ui.stop_waiting":             "Waiting for %d transfers to finish, stopping anyway in %d s…",
+	"ui.browser_address":          "Open in a browser:",
+	"ui.dav_address":              "Network drive:",
+	"ui.sharing":                  "Sharing",
+	"ui.open_storage_failed":      "Failed to open the shared storage",
+	"ui.send_to_other":            "Send to another computer",
+	"ui.require_approval":         "Ask me before accepting uploads",
+	"ui.follow_symlinks":          "Allow symbolic links inside the shared folder",
+	"ui.minimize_to_tray":         "Minimize to tray when closing the window",
+	"ui.tray_show":                "Show window",
+	"ui.tray_address":             "Address and QR code",
+	"ui.tray_open_folder":         "Open shared folder",
+	"ui.tray_quit":                "Quit",
+	"ui.tray_sharing":             "Sharing at %s",
+	"ui.tray_idle":                "Not sharing",
+	"ui.tray_not_sharing":         "Sharing has not started yet",
+	"ui.tray_hidden":              "Kuaichuan is still running in the tray and keeps sharing",
+	"ui.notify_uploads":           "Notify me when files arrive",
+	"ui.notify_file":              "File received",
+	"ui.notify_file_body":         "%s (%s)",
+	"ui.notify_files":             "%d files received",
+	"ui.notify_files_body":        "%s and more, %s in total",
+	"ui.notify_from":              "From %s",
+	"ui.notify_reveal":            "Show received files",
+	"ui.notify_none":              "No files received yet, or they are not stored locally",
+	"ui.settings":                 "Settings",
+	"ui.settings_general":         "General",
+	"ui.settings_auth":            "Password",
+	"ui.settings_limits":          "Upload limits",
+	"ui.settings_ui":              "Interface",
+	"ui.settings_port":            "Port",
+	"ui.settings_bind":            "Listen address",
+	"ui.settings_bind_all":        "All interfaces",
+	"ui.settings_auto_start":      "Resume sharing on launch if it was running at exit",
+	"ui.settings_auth_user":       "Username",
+	"ui.settings_auth_password":   "Password",
+	"ui.settings_auth_any_user":   "Any username",
+	"ui.settings_auth_none":       "No password",
+	"ui.settings_auth_keep":       "Leave empty to keep the current password",
+	"ui.settings_auth_clear":      "Clear",
+	"ui.settings_auth_hint":       "Browsers and network drives must log in; share links are not affected",
+	"ui.settings_max_file":        "Max file size (MB)",
+	"ui.settings_client_quota":    "Per device limit (MB)",
+	"ui.settings_share_quota":     "Shared folder limit (MB)",
+	"ui.settings_min_free":        "Keep free disk space (MB)",
+	"ui.settings_preallocate":     "Preallocate disk space before receiving",
+	"ui.settings_limits_hint":     "0 means no limit",
+	"ui.settings_save":            "Save",
+	"ui.settings_save_failed":     "Failed to save settings: %v",
+	"ui.settings_restart_sharing": "Settings saved. Port, listen address, password and upload limits apply after sharing is restarted",
+	"ui.settings_restart_app":     "Settings saved. The language applies after the app is reopened",
+	"ui.start_failed":             "Failed to start sharing",
+	"ui.config_unreadable":        "The config file could not be read (%v). Defaults are used and the file was kept as %s",
+	"ui.config_repaired":          "These settings in the config file were invalid and have been reset to defaults:",
+	"ui.config_version":           "Config file version %d is not supported, it may come from a newer release",
+	"ui.config_port":              "Port %d is invalid, it must be between 1 and 65535",
+	"ui.config_bind":              "Listen address %s is not a valid IP address",
+	"ui.config_mode":              "Unknown share mode %s",
+	"ui.config_auth":              "A password is required when a username is set",
+	"ui.config_limits":            "Upload limits cannot be negative",
+	"ui.config_throttle":          "Speed limits cannot be negative",
+	"ui.config_throttle_client":   "The speed limit for device %s is invalid",
+	"ui.config_storage":           "Unknown storage type %s",
+	"ui.config_s3_bucket":         "A bucket is required for S3 storage",
+	"ui.config_cors":              "The CORS preflight max age cannot be negative",
+	"ui.config_audit":             "Audit log size and file count cannot be negative",
+	"ui.config_language":          "Unsupported language %s",
+	"ui.share_mode":               "Share mode:",
+	"ui.mode_readwrite":           "Upload and download",
+	"ui.mode_readonly":            "Read-only",
+	"ui.mode_uploadonly":          "Upload only",
+	"ui.language":                 "Language (applies after restart):",
+	"ui.lang_auto":                "System default",
+	"ui.tab_devices":              "Connected devices",
+	"ui.tab_files":                "Files",
+	"ui.files_empty":              "The shared folder is empty",
+	"ui.files_from":               "From %s",
+	"ui.files_from_link":          "Uploaded by %s via file request",
+	"ui.files_uploaded":           "Uploaded",
+	"ui.files_open":               "Open",
+	"ui.files_reveal":             "Show in folder",
+	"ui.files_rename":             "Rename",
+	"ui.files_new_name":           "New name",
+	"ui.files_name_invalid":       "The name cannot be empty or contain / or \\",
+	"ui.files_exists":             "%s already exists",
+	"ui.files_delete":             "Delete",
+	"ui.files_delete_confirm":     "Delete %s? Folders are deleted with everything in them. This cannot be undone.",
+	"ui.files_share_link":         "Share link",
+	"ui.shared_folder":            "Shared folder:",
+	"ui.throttle":                 "Speed limits",
+	"ui.approval_title":           "Upload request",
+	"ui.approval_request":         "Device %s (%s) wants to upload %d files (%s in total)",
+	"ui.approval_more":            "…%d files in total",
+	"ui.accept":                   "Accept",
+	"ui.decline":                  "Decline",
+	"ui.rate_invalid":             "Enter a whole number of 0 or more",
+	"ui.rate_upload":              "Total upload (KB/s)",
+	"ui.rate_download":            "Total download (KB/s)",
+	"ui.rate_client_upload":       "Upload per device (KB/s)",
+	"ui.rate_client_download":     "Download per device (KB/s)",
+	"ui.rate_clients":             "Per-device limits",
+	"ui.rate_clients_empty":       "No per-device limits",
+	"ui.rate_client_add":          "Add",
+	"ui.rate_client_title":        "Device speed limit",
+	"ui.rate_client_ip":           "IP address",
+	"ui.rate_client_ip_invalid":   "Enter a valid IP address",
+	"ui.rate_client_summary":      "%s  upload %s · download %s",
+	"ui.rate_single_upload":       "Upload (KB/s)",
+	"ui.rate_single_download":     "Download (KB/s)",
+	"ui.rate_unlimited":           "unlimited",
+	"ui.apply":                    "Apply",
+	"ui.throttle_applied":         "Speed limits applied (0 means unlimited)",
+	"ui.device_name":              "Device name",
+	"ui.details":                  "Details",
+	"ui.rename":                   "Rename",
+	"ui.disconnect":               "Disconnect",
+	"ui.block_session":            "Block for now",
+	"ui.block_permanent":          "Block permanently",
+	"ui.unblock":                  "Unblock",
+	"ui.rename_device":            "Rename device",
+	"ui.name":                     "Name",
+	"ui.save":                     "Save",
+	"ui.cancel":                   "Cancel",
+	"ui.close":                    "Close",
+	"ui.block_confirm":            "Block device %s permanently?",
+	"ui.status_idle":              "Idle",
+	"ui.status_transferring":      "Transferring",
+	"ui.device_detail":            "%s · %s · last seen %s · up %s · down %s",
+	"ui.send_addr_placeholder":    "Or enter an address, e.g. 192.168.1.10:8000",
+	"ui.send_searching":           "Looking for computers sharing on the local network…",
+	"ui.send_target_required":     "Choose or enter a recipient",
+	"ui.send_file":                "Send file",
+	"ui.send_folder":              "Send folder",
+	"ui.send_waiting":             "Waiting for the recipient to accept…",
+	"ui.sending":                  "Sending",
+	"ui.send_failed":              "Send failed",
+	"ui.send_done":                "Sent",
+	"ui.send_done_detail":         "The files were sent to %s",
+	"ui.send_login":               "Login required",
+	"ui.send_login_message":       "%s requires a password to share files",
+	"ui.send_login_wrong":         "Wrong username or password, please try again",
+	"ui.send_login_ok":            "Send",
+	"ui.tab_audit":                "Access log",
+	"ui.audit_disabled":           "The access log is turned off",
+	"ui.audit_filter":             "Filter by IP, device, path…",
+	"ui.audit_time":               "Time",
+	"ui.audit_ip":                 "IP",
+	"ui.audit_action":             "Action",
+	"ui.audit_path":               "Path",
+	"ui.audit_bytes":              "Size",
+	"ui.audit_duration":           "Duration",
+	"ui.audit_result":             "Result",
+	"ui.audit_ok":                 "OK",
+	"ui.audit_list":               "Browse",
+	"ui.audit_search":             "Search",
+	"ui.audit_download":           "Download",
+	"ui.audit_upload":             "Upload",
+	"ui.audit_delete":             "Delete",
+	"ui.audit_move":               "Move",
+	"ui.audit_copy":               "Copy",
+	"ui.audit_mkdir":              "New folder",
+	"ui.audit_export_csv":         "Export CSV",
+	"ui.audit_export_json":        "Export JSON",
+	"ui.audit_exported":           "Access log exported",
+	"ui.refresh":                  "Refresh",
+	"ui.tab_links":                "Share links",
+	"ui.link_new":                 "New share link",
+	"ui.link_file":                "Share a file",
+	"ui.link_folder":              "Share a folder",
+	"ui.link_target":              "Shared item",
+	"ui.link_expiry":              "Expires after",
+	"ui.link_expiry_hour":         "1 hour",
+	"ui.link_expiry_day":          "1 day",
+	"ui.link_expiry_week":         "7 days",
+	"ui.link_expiry_month":        "30 days",
+	"ui.link_expiry_never":        "Never",
+	"ui.link_max_downloads":       "Max downloads (0 for unlimited)",
+	"ui.link_password":            "Password (optional)",
+	"ui.link_create":              "Create",
+	"ui.link_outside_share":       "Only files inside the shared folder can be shared",
+	"ui.link_target_required":     "Choose a file or folder to share",
+	"ui.link_none":                "No share links yet",
+	"ui.link_detail":              "%s · downloaded %s · %s",
+	"ui.link_until":               "valid until %s",
+	"ui.link_expired":             "expired",
+	"ui.link_exhausted":           "download limit reached",
+	"ui.link_other_share":         "belongs to another shared folder",
+	"ui.link_protected":           "password",
+	"ui.link_qr":                  "QR code",
+	"ui.link_copy":                "Copy link",
+	"ui.link_copied":              "Link copied",
+	"ui.link_revoke":              "Revoke",
+	"ui.link_revoke_confirm":      "Revoke the share link for %s? It will stop working immediately.",
+	"ui.link_not_sharing":         "Links can be opened once sharing is started",
+	"ui.temp_title":               "Temporary share: %s",
+	"ui.drop_title":               "Share dropped files",
+	"ui.drop_message":             "%d item(s) dropped: %s",
+	"ui.drop_hint":                "Copied files are visible to every device. A temporary share leaves the files where they are, is only reachable through its link and ends when Kuaichuan quits.",
+	"ui.drop_copy":                "Copy to shared folder",
+	"ui.drop_temp":                "Share temporarily",
+	"ui.drop_copying":             "Copying to the shared folder…",
+	"ui.drop_copy_failed":         "Copy failed",
+	"ui.drop_copied":              "Copied to the shared folder: %s",
+	"ui.request_new":              "New file request",
+	"ui.request_folder":           "Save to",
+	"ui.request_choose_folder":    "Choose folder",
+	"ui.request_subfolder":        "Subfolder",
+	"ui.request_subfolder_hint":   "Optional, created if missing",
+	"ui.request_max_files":        "Max files (0 for unlimited)",
+	"ui.request_max_size":         "Max file size in MB (0 for unlimited)",
+	"ui.request_types":            "Allowed types",
+	"ui.request_types_hint":       "Optional, e.g. .jpg, .pdf, image/*",
+	"ui.request_detail":           "%s · file request, received %s · %s",
+	"ui.request_full":             "full",
+
+	// 网页，会被嵌入脚本中的字符串，不能包含引号和尖括号
+	"web.html_lang":                  "en",
+	"web.list_title":                 "Shared Files",
+	"web.wechat_tip_before":          "Tap",
+	"web.wechat_tip_after":           "in the top right corner and choose to open in a browser",
+	"web.back":                       "Back",
+	"web.rename":                     "Rename",
+	"web.search_placeholder":         "Search all files, pinyin, initials and * wildcards supported",
+	"web.no_files":                   "No files are shared",
+	"web.upload":                     "Upload",
+	"web.empty_folder":               "This folder is empty",
+	"web.searching":                  "Searching…",
+	"web.search_found":               "{n} results found",
+	"web.search_truncated":           "{n} results found (showing the first {n})",
+	"web.search_none":                "No matching files",
+	"web.search_failed":              "Search failed",
+	"web.device_name_prompt":         "Name this device",
+	"web.upload_title":               "LAN File Transfer",
+	"web.all_files":                  "All files",
+	"web.upload_heading":             "Upload shared files",
+	"web.upload_intro":               "Upload files to share them on this computer. Drag and drop, multiple files and resuming are supported.",
+	"web.drop_here":                  "Drop files here to upload",
+	"web.or":                         "or",
+	"web.choose_files":               "Choose files",
+	"web.limit_tip":                  "Allowed types: all files, maximum file size: {size}",
+	"web.unlimited":                  "unlimited",
+	"web.today_uploads":              "Uploaded today",
+	"web.files_unit":                 "files",
+	"web.total_size":                 "Total size",
+	"web.uploaded":                   "uploaded",
+	"web.upload_speed":               "Upload speed",
+	"web.current_speed":              "current speed",
+	"web.recent":                     "Recent uploads",
+	"web.clear_history":              "Clear history",
+	"web.no_history":                 "No uploads yet",
+	"web.upload_addr":                "Upload address: {url}",
+	"web.ip_failed":                  "Could not get the IP address, please check the network connection",
+	"web.file_added":                 "File already added",
+	"web.file_in_list":               "This file is already in the upload list",
+	"web.waiting_approval":           "Waiting for approval",
+	"web.waiting":                    "Waiting",
+	"web.preparing":                  "Preparing",
+	"web.uploading":                  "Uploading {p}%",
+	"web.upload_done":                "Uploaded",
+	"web.upload_success":             "Upload complete",
+	"web.upload_success_detail":      "{name} was uploaded",
+	"web.upload_failed":              "Upload failed",
+	"web.upload_failed_detail":       "{name} failed to upload: {reason}",
+	"web.network_error":              "Network error",
+	"web.bad_response":               "Invalid response from the server",
+	"web.cancelled":                  "Cancelled",
+	"web.upload_cancelled":           "Upload cancelled",
+	"web.upload_cancelled_detail":    "The upload of {name} was cancelled",
+	"web.download":                   "Download",
+	"web.delete":                     "Delete",
+	"web.deleted":                    "Deleted",
+	"web.deleted_detail":             "{name} was deleted from the server",
+	"web.delete_failed":              "Delete failed",
+	"web.delete_failed_detail":       "Could not delete {name}",
+	"web.delete_error":               "An error occurred while deleting the file",
+	"web.history_cleared":            "History cleared",
+	"web.history_cleared_detail":     "All upload history was cleared",
+	"web.clear_failed":               "Clear failed",
+	"web.clear_failed_detail":        "Could not clear the history",
+	"web.clear_error":                "An error occurred while clearing the history",
+	"web.server_stopping":            "Sharing will stop in {n} seconds, please finish your transfers",
+	"web.server_stopped":             "Sharing has stopped",
+	"web.share_title":                "Shared with you",
+	"web.share_password":             "This share is password protected",
+	"web.share_password_placeholder": "Enter password",
+	"web.share_unlock":               "Open",
+	"web.share_until":                "Valid until {time}",
+	"web.share_no_expiry":            "Does not expire",
+	"web.share_remaining":            "{n} downloads left",
+	"web.share_unlimited":            "Unlimited downloads",
+	"web.share_unavailable":          "Share unavailable",
+	"web.request_title":              "Submit files",
+	"web.request_intro":              "Files will be uploaded to {name}. You will not be able to view or delete them afterwards.",
+	"web.request_name":               "Your name",
+	"web.request_note":               "Note (optional)",
+	"web.request_remaining":          "{n} more files accepted",
+	"web.request_max_size":           "Max {size} per file",
+	"web.request_types":              "Accepted types: {types}",
+	"web.request_submit":             "Upload",
+	"web.request_done":               "All files uploaded, thank you!",
+}
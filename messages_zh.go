@@ -0,0 +1,361 @@
+package main
+
+// 中文文本
+var messagesZH = map[string]string{
+	// API 错误，消息ID即错误码
+	"not_found":               "接口不存在",
+	"method_not_allowed":      "方法不允许",
+	"bad_request":             "请求格式错误",
+	"missing_file":            "缺少文件",
+	"missing_filename":        "缺少文件名",
+	"file_not_found":          "文件不存在",
+	"dir_not_found":           "目录不存在",
+	"storage_unavailable":     "无法打开共享文件夹",
+	"upload_forbidden_path":   "不允许上传到该位置",
+	"upload_failed":           "上传失败: %v",
+	"upload_target_is_dir":    "同名的文件夹已存在",
+	"upload_denied":           "对方拒绝了上传请求",
+	"upload_approval_timeout": "等待对方确认超时",
+	"device_blocked":          "该设备已被禁止访问",
+	"device_name_too_long":    "名称不能超过32个字符",
+	"search_missing_query":    "缺少搜索关键字",
+	"share_read_only":         "共享为只读模式，不能上传",
+	"share_upload_only":       "共享为仅上传模式，不能查看文件",
+	"auth_required":           "需要登录才能访问共享",
+	"limit_file_size":         "文件大小超过限制（最大 %s）",
+	"limit_client_quota":      "已超过本设备的上传配额（%s）",
+	"limit_share_quota":       "共享文件夹已超过容量配额（%s）",
+	"limit_disk_full":         "磁盘空间不足",
+	"server_stopping":         "共享正在停止",
+	"link_not_found":          "分享链接不存在或已被撤销",
+	"link_expired":            "分享链接已过期",
+	"link_exhausted":          "分享链接的下载次数已用完",
+	"link_password_required":  "需要输入分享密码",
+	"link_password_wrong":     "密码错误",
+	"link_full":               "收集的文件数量已满",
+	"link_type_not_allowed":   "不支持该文件类型，只能上传 %s",
+
+	// 接口的成功提示
+	"msg.upload_ok":       "文件上传成功",
+	"msg.upload_approved": "对方已同意接收",
+
+	// 桌面端
+	"ui.window_title":             "快传-局域网文件共享",
+	"ui.got_it":                   "知道了",
+	"ui.select_folder":            "选择共享文件夹",
+	"ui.folder_updated":           "上传目录已更新",
+	"ui.folders":                  "最近",
+	"ui.folder_favorite":          "收藏当前文件夹",
+	"ui.folder_unfavorite":        "取消收藏当前文件夹",
+	"ui.folder_clear_recent":      "清除最近记录",
+	"ui.folder_none":              "还没有最近或收藏的文件夹",
+	"ui.folder_missing":           "文件夹不存在: %s",
+	"ui.open":                     "打开",
+	"ui.folder_required":          "请选择共享文件夹",
+	"ui.start_sharing":            "开始共享",
+	"ui.stop_sharing":             "停止共享",
+	"ui.stop_title":               "停止共享",
+	"ui.stop_active":              "有 %d 个传输正在进行，停止共享会中断它们",
+	"ui.stop_wait":                "等待完成（最多 %d 秒）",
+	"ui.stop_force":               "立即停止",
+	"ui.stop_waiting":             "正在等待 %d 个传输完成，%d 秒后强制停止……",
+	"ui.browser_address":          "浏览器访问:",
+	"ui.dav_address":              "网络驱动器:",
+	"ui.sharing":                  "正在共享",
+	"ui.open_storage_failed":      "打开共享存储失败",
+	"ui.send_to_other":            "发送到其他电脑",
+	"ui.require_approval":         "接收上传前需要我确认",
+	"ui.follow_symlinks":          "允许访问共享文件夹内的符号链接",
+	"ui.minimize_to_tray":         "关闭窗口时最小化到托盘",
+	"ui.tray_show":                "显示窗口",
+	"ui.tray_address":             "访问地址和二维码",
+	"ui.tray_open_folder":         "打开共享文件夹",
+	"ui.tray_quit":                "退出",
+	"ui.tray_sharing":             "正在共享 %s",
+	"ui.tray_idle":                "未在共享",
+	"ui.tray_not_sharing":         "还没有开始共享",
+	"ui.tray_hidden":              "快传仍在托盘中运行，共享不会中断",
+	"ui.notify_uploads":           "收到文件时通知我",
+	"ui.notify_file":              "收到文件",
+	"ui.notify_file_body":         "%s（%s）",
+	"ui.notify_files":             "收到 %d 个文件",
+	"ui.notify_files_body":        "%s 等，共 %s",
+	"ui.notify_from":              "来自 %s",
+	"ui.notify_reveal":            "显示刚收到的文件",
+	"ui.notify_none":              "还没有收到文件，或文件不在本地",
+	"ui.settings":                 "设置",
+	"ui.settings_general":         "常规",
+	"ui.settings_auth":            "访问密码",
+	"ui.settings_limits":          "上传限制",
+	"ui.settings_ui":              "界面",
+	"ui.settings_port":            "端口",
+	"ui.settings_bind":            "监听地址",
+	"ui.settings_bind_all":        "所有网卡",
+	"ui.settings_auto_start":      "启动时恢复上次退出时的共享",
+	"ui.settings_auth_user":       "用户名",
+	"ui.settings_auth_password":   "密码",
+	"ui.settings_auth_any_user":   "任意用户名",
+	"ui.settings_auth_none":       "不设置密码",
+	"ui.settings_auth_keep":       "留空保持原密码",
+	"ui.settings_auth_clear":      "清除密码",
+	"ui.settings_auth_hint":       "设置后浏览器和网络驱动器需要登录，分享链接不受影响",
+	"ui.settings_max_file":        "单个文件上限 (MB)",
+	"ui.settings_client_quota":    "每台设备上限 (MB)",
+	"ui.settings_share_quota":     "共享文件夹上限 (MB)",
+	"ui.settings_min_free":        "至少保留空闲空间 (MB)",
+	"ui.settings_preallocate":     "接收前预先分配磁盘空间",
+	"ui.settings_limits_hint":     "0 表示不限制",
+	"ui.settings_save":            "保存",
+	"ui.settings_save_failed":     "保存设置失败: %v",
+	"ui.settings_restart_sharing": "设置已保存，端口、监听地址、访问密码和上传限制在重新开始共享后生效",
+	"ui.settings_restart_app":     "设置已保存，界面语言在重新打开后生效",
+	"ui.start_failed":             "开始共享失败",
+	"ui.config_unreadable":        "配置文件无法读取（%v），已使用默认设置，原文件保存为 %s",
+	"ui.config_repaired":          "配置文件中以下设置有误，已恢复默认值：",
+	"ui.config_version":           "配置文件版本 %d 不受支持，可能来自更新的版本",
+	"ui.config_port":              "端口 %d 无效，应为 1 到 65535",
+	"ui.config_bind":              "监听地址 %s 不是有效的 IP 地址",
+	"ui.config_mode":              "未知的共享模式 %s",
+	"ui.config_auth":              "设置了用户名时必须设置密码",
+	"ui.config_limits":            "上传限制不能为负数",
+	"ui.config_throttle":          "限速不能为负数",
+	"ui.config_throttle_client":   "设备 %s 的限速设置不正确",
+	"ui.config_storage":           "未知的存储类型 %s",
+	"ui.config_s3_bucket":         "使用 S3 存储时必须设置存储桶",
+	"ui.config_cors":              "跨域预检缓存时间不能为负数",
+	"ui.config_audit":             "访问记录的大小和文件数不能为负数",
+	"ui.config_language":          "不支持的界面语言 %s",
+	"ui.share_mode":               "共享模式:",
+	"ui.mode_readwrite":           "可上传和下载",
+	"ui.mode_readonly":            "只读",
+	"ui.mode_uploadonly":          "仅上传",
+	"ui.language":                 "界面语言（重启后生效）:",
+	"ui.lang_auto":                "跟随系统",
+	"ui.tab_devices":              "已连接设备",
+	"ui.tab_files":                "文件",
+	"ui.files_empty":              "共享文件夹是空的",
+	"ui.files_from":               "来自 %s",
+	"ui.files_from_link":          "%s 通过收集链接上传",
+	"ui.files_uploaded":           "上传的文件",
+	"ui.files_open":               "打开",
+	"ui.files_reveal":             "在文件夹中显示",
+	"ui.files_rename":             "重命名",
+	"ui.files_new_name":           "新名称",
+	"ui.files_name_invalid":       "名称不能为空，也不能包含 / 或 \\",
+	"ui.files_exists":             "已存在名为 %s 的文件或文件夹",
+	"ui.files_delete":             "删除",
+	"ui.files_delete_confirm":     "确定删除 %s 吗？文件夹会连同其中的文件一起删除，无法恢复。",
+	"ui.files_share_link":         "分享链接",
+	"ui.shared_folder":            "共享文件夹:",
+	"ui.throttle":                 "限速设置",
+	"ui.approval_title":           "收到上传请求",
+	"ui.approval_request":         "设备 %s（%s）请求上传 %d 个文件（共 %s）",
+	"ui.approval_more":            "……等 %d 个文件",
+	"ui.accept":                   "接收",
+	"ui.decline":                  "拒绝",
+	"ui.rate_invalid":             "请输入不小于0的整数",
+	"ui.rate_upload":              "总上传 (KB/s)",
+	"ui.rate_download":            "总下载 (KB/s)",
+	"ui.rate_client_upload":       "每台设备上传 (KB/s)",
+	"ui.rate_client_download":     "每台设备下载 (KB/s)",
+	"ui.rate_clients":             "单独限速的设备",
+	"ui.rate_clients_empty":       "没有单独限速的设备",
+	"ui.rate_client_add":          "添加",
+	"ui.rate_client_title":        "设备限速",
+	"ui.rate_client_ip":           "IP 地址",
+	"ui.rate_client_ip_invalid":   "请输入有效的IP地址",
+	"ui.rate_client_summary":      "%s  上传 %s · 下载 %s",
+	"ui.rate_single_upload":       "上传 (KB/s)",
+	"ui.rate_single_download":     "下载 (KB/s)",
+	"ui.rate_unlimited":           "不限速",
+	"ui.apply":                    "应用",
+	"ui.throttle_applied":         "限速设置已生效（0 表示不限速）",
+	"ui.device_name":              "设备名称",
+	"ui.details":                  "详情",
+	"ui.rename":                   "改名",
+	"ui.disconnect":               "断开",
+	"ui.block_session":            "本次禁止",
+	"ui.block_permanent":          "永久禁止",
+	"ui.unblock":                  "解除禁止",
+	"ui.rename_device":            "修改设备名称",
+	"ui.name":                     "名称",
+	"ui.save":                     "保存",
+	"ui.cancel":                   "取消",
+	"ui.close":                    "关闭",
+	"ui.block_confirm":            "确定永久禁止设备 %s 吗？",
+	"ui.status_idle":              "空闲",
+	"ui.status_transferring":      "传输中",
+	"ui.device_detail":            "%s · %s · 最近访问 %s · 上传 %s · 下载 %s",
+	"ui.send_addr_placeholder":    "或输入对方地址，如 192.168.1.10:8000",
+	"ui.send_searching":           "正在查找局域网中正在共享的快传……",
+	"ui.send_target_required":     "请选择或输入接收方",
+	"ui.send_file":                "发送文件",
+	"ui.send_folder":              "发送文件夹",
+	"ui.send_waiting":             "正在等待对方确认……",
+	"ui.sending":                  "正在发送",
+	"ui.send_failed":              "发送失败",
+	"ui.send_done":                "发送完成",
+	"ui.send_done_detail":         "文件已发送到 %s",
+	"ui.send_login":               "需要登录",
+	"ui.send_login_message":       "%s 设置了访问密码，请输入账号密码",
+	"ui.send_login_wrong":         "账号或密码不正确，请重新输入",
+	"ui.send_login_ok":            "发送",
+	"ui.tab_audit":                "访问记录",
+	"ui.audit_disabled":           "访问记录已关闭",
+	"ui.audit_filter":             "按 IP、设备、路径等筛选",
+	"ui.audit_time":               "时间",
+	"ui.audit_ip":                 "IP",
+	"ui.audit_action":             "操作",
+	"ui.audit_path":               "路径",
+	"ui.audit_bytes":              "大小",
+	"ui.audit_duration":           "耗时",
+	"ui.audit_result":             "结果",
+	"ui.audit_ok":                 "成功",
+	"ui.audit_list":               "浏览",
+	"ui.audit_search":             "搜索",
+	"ui.audit_download":           "下载",
+	"ui.audit_upload":             "上传",
+	"ui.audit_delete":             "删除",
+	"ui.audit_move":               "移动",
+	"ui.audit_copy":               "复制",
+	"ui.audit_mkdir":              "新建文件夹",
+	"ui.audit_export_csv":         "导出 CSV",
+	"ui.audit_export_json":        "导出 JSON",
+	"ui.audit_exported":           "访问记录已导出",
+	"ui.refresh":                  "刷新",
+	"ui.tab_links":                "分享链接",
+	"ui.link_new":                 "新建分享链接",
+	"ui.link_file":                "分享文件",
+	"ui.link_folder":              "分享文件夹",
+	"ui.link_target":              "分享内容",
+	"ui.link_expiry":              "有效期",
+	"ui.link_expiry_hour":         "1 小时",
+	"ui.link_expiry_day":          "1 天",
+	"ui.link_expiry_week":         "7 天",
+	"ui.link_expiry_month":        "30 天",
+	"ui.link_expiry_never":        "永久有效",
+	"ui.link_max_downloads":       "最多下载次数（0 表示不限）",
+	"ui.link_password":            "密码（可选）",
+	"ui.link_create":              "创建",
+	"ui.link_outside_share":       "只能分享共享文件夹内的文件",
+	"ui.link_target_required":     "请选择要分享的文件或文件夹",
+	"ui.link_none":                "还没有分享链接",
+	"ui.link_detail":              "%s · 已下载 %s · %s",
+	"ui.link_until":               "有效期至 %s",
+	"ui.link_expired":             "已过期",
+	"ui.link_exhausted":           "下载次数已用完",
+	"ui.link_other_share":         "属于其他共享文件夹",
+	"ui.link_protected":           "有密码",
+	"ui.link_qr":                  "二维码",
+	"ui.link_copy":                "复制链接",
+	"ui.link_copied":              "链接已复制",
+	"ui.link_revoke":              "撤销",
+	"ui.link_revoke_confirm":      "确定撤销 %s 的分享链接吗？撤销后链接立即失效。",
+	"ui.link_not_sharing":         "链接在开始共享后才能访问",
+	"ui.temp_title":               "临时分享：%s",
+	"ui.drop_title":               "分享拖入的文件",
+	"ui.drop_message":             "已拖入 %d 项：%s",
+	"ui.drop_hint":                "复制到共享文件夹后所有设备都能看到；临时分享不移动文件，只能通过生成的链接访问，退出快传后失效。",
+	"ui.drop_copy":                "复制到共享文件夹",
+	"ui.drop_temp":                "临时分享",
+	"ui.drop_copying":             "正在复制到共享文件夹……",
+	"ui.drop_copy_failed":         "复制失败",
+	"ui.drop_copied":              "已复制到共享文件夹：%s",
+	"ui.request_new":              "新建收集链接",
+	"ui.request_folder":           "保存到",
+	"ui.request_choose_folder":    "选择文件夹",
+	"ui.request_subfolder":        "子文件夹",
+	"ui.request_subfolder_hint":   "可选，不存在时自动创建",
+	"ui.request_max_files":        "最多文件数（0 表示不限）",
+	"ui.request_max_size":         "单个文件最大 MB（0 表示不限）",
+	"ui.request_types":            "允许的类型",
+	"ui.request_types_hint":       "可选，如 .jpg, .pdf, image/*",
+	"ui.request_detail":           "%s · 收集文件，已收到 %s · %s",
+	"ui.request_full":             "已收满",
+
+	// 网页，会被嵌入脚本中的字符串，不能包含引号和尖括号
+	"web.html_lang":                  "zh-CN",
+	"web.list_title":                 "云文件管理器",
+	"web.wechat_tip_before":          "请点击右上角",
+	"web.wechat_tip_after":           "选择在浏览器中打开",
+	"web.back":                       "返回",
+	"web.rename":                     "修改名称",
+	"web.search_placeholder":         "搜索全部文件，支持拼音、首字母和通配符 *",
+	"web.no_files":                   "没有共享任何文件",
+	"web.upload":                     "上传",
+	"web.empty_folder":               "该目录为空",
+	"web.searching":                  "正在搜索……",
+	"web.search_found":               "找到 {n} 个结果",
+	"web.search_truncated":           "找到 {n} 个结果（仅显示前 {n} 个）",
+	"web.search_none":                "没有找到匹配的文件",
+	"web.search_failed":              "搜索失败",
+	"web.device_name_prompt":         "请输入本机名称",
+	"web.upload_title":               "局域网文件快传",
+	"web.all_files":                  "全部文件",
+	"web.upload_heading":             "上传共享文件",
+	"web.upload_intro":               "将需要共享文件上传到这台计算机。支持拖拽上传、多文件上传和断点续传。",
+	"web.drop_here":                  "拖放文件到此处上传",
+	"web.or":                         "或者",
+	"web.choose_files":               "选择文件上传",
+	"web.limit_tip":                  "支持的格式: 所有文件类型，最大文件大小: {size}",
+	"web.unlimited":                  "无限制",
+	"web.today_uploads":              "今日上传",
+	"web.files_unit":                 "个文件",
+	"web.total_size":                 "总上传大小",
+	"web.uploaded":                   "已上传",
+	"web.upload_speed":               "上传速度",
+	"web.current_speed":              "当前速度",
+	"web.recent":                     "最近上传",
+	"web.clear_history":              "清空历史",
+	"web.no_history":                 "暂无上传历史",
+	"web.upload_addr":                "上传地址: {url}",
+	"web.ip_failed":                  "无法获取IP地址，请确保网络连接正常",
+	"web.file_added":                 "文件已添加",
+	"web.file_in_list":               "该文件已在上传列表中",
+	"web.waiting_approval":           "等待对方确认",
+	"web.waiting":                    "等待中",
+	"web.preparing":                  "准备上传",
+	"web.uploading":                  "上传中 {p}%",
+	"web.upload_done":                "上传完成",
+	"web.upload_success":             "上传成功",
+	"web.upload_success_detail":      "{name} 已成功上传",
+	"web.upload_failed":              "上传失败",
+	"web.upload_failed_detail":       "{name} 上传失败: {reason}",
+	"web.network_error":              "网络错误",
+	"web.bad_response":               "服务器响应格式错误",
+	"web.cancelled":                  "已取消",
+	"web.upload_cancelled":           "上传已取消",
+	"web.upload_cancelled_detail":    "{name} 的上传已取消",
+	"web.download":                   "下载",
+	"web.delete":                     "删除",
+	"web.deleted":                    "已删除",
+	"web.deleted_detail":             "{name} 已从服务器删除",
+	"web.delete_failed":              "删除失败",
+	"web.delete_failed_detail":       "无法删除 {name}",
+	"web.delete_error":               "删除文件时发生错误",
+	"web.history_cleared":            "已清空历史",
+	"web.history_cleared_detail":     "所有上传历史记录已被清除",
+	"web.clear_failed":               "清空失败",
+	"web.clear_failed_detail":        "无法清空历史记录",
+	"web.clear_error":                "清空历史记录时发生错误",
+	"web.server_stopping":            "共享将在 {n} 秒后停止，请尽快完成传输",
+	"web.server_stopped":             "共享已停止",
+	"web.share_title":                "文件分享",
+	"web.share_password":             "该分享需要输入密码",
+	"web.share_password_placeholder": "请输入密码",
+	"web.share_unlock":               "确定",
+	"web.share_until":                "{time} 前有效",
+	"web.share_no_expiry":            "长期有效",
+	"web.share_remaining":            "还可下载 {n} 次",
+	"web.share_unlimited":            "不限下载次数",
+	"web.share_unavailable":          "分享不可用",
+	"web.request_title":              "提交文件",
+	"web.request_intro":              "文件将上传到 {name}，上传后你无法查看或删除。",
+	"web.request_name":               "你的名字",
+	"web.request_note":               "备注（可选）",
+	"web.request_remaining":          "还可上传 {n} 个文件",
+	"web.request_max_size":           "单个文件最大 {size}",
+	"web.request_types":              "允许的类型: {types}",
+	"web.request_submit":             "上传",
+	"web.request_done":               "已全部上传，谢谢！",
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Prometheus 指标配置，默认关闭。密码和令牌与访问密码一样只保存加盐的哈希
+type MetricsConfig struct {
+	Enabled      bool   `json:"enabled"`
+	Username     string `json:"username"` // 设置后需要 Basic 认证，都未设置时使用共享的访问密码
+	PasswordHash string `json:"passwordHash,omitempty"`
+	TokenHash    string `json:"tokenHash,omitempty"` // 设置后可用 Authorization: Bearer 访问
+	Salt         string `json:"salt,omitempty"`
+}
+
+// 设置密码和令牌，为空的保持不变
+func (c *MetricsConfig) setSecrets(password, token string) {
+	if password == "" && token == "" {
+		return
+	}
+	salt, err := hex.DecodeString(c.Salt)
+	if err != nil || len(salt) == 0 {
+		salt = make([]byte, 16)
+		rand.Read(salt)
+		c.Salt = hex.EncodeToString(salt)
+		c.PasswordHash, c.TokenHash = "", ""
+	}
+	if password != "" {
+		c.PasswordHash = hashLinkPassword(password, salt)
+	}
+	if token != "" {
+		c.TokenHash = hashLinkPassword(token, salt)
+	}
+}
+
+// 是否需要认证
+func (c MetricsConfig) protected() bool {
+	return c.Username != "" || c.PasswordHash != "" || c.TokenHash != ""
+}
+
+// 请求是否通过认证
+func (c MetricsConfig) authorized(r *http.Request) bool {
+	if !c.protected() {
+		return true
+	}
+	if c.TokenHash != "" {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok &&
+			verifyPassword(token, c.Salt, c.TokenHash) {
+			return true
+		}
+	}
+	if c.Username != "" || c.PasswordHash != "" {
+		user, pass, ok := r.BasicAuth()
+		if !ok || subtle.ConstantTimeCompare([]byte(user), []byte(c.Username)) != 1 {
+			return false
+		}
+		if c.PasswordHash == "" {
+			return pass == ""
+		}
+		return verifyPassword(pass, c.Salt, c.PasswordHash)
+	}
+	return false
+}
+
+// 上传耗时直方图的分桶，单位秒
+var uploadDurationBuckets = []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600}
+
+type requestKey struct {
+	route  string
+	method string
+	code   int
+}
+
+// 传输服务器的运行指标
+type serverMetrics struct {
+	mu              sync.Mutex
+	requests        map[requestKey]uint64
+	uploadedBytes   int64
+	downloadedBytes int64
+	activeUploads   int64
+	activeDownloads int64
+	uploadCounts    []uint64 // 各分桶的次数，不累加
+	uploadCount     uint64
+	uploadSum       float64
+}
+
+func newServerMetrics() *serverMetrics {
+	return &serverMetrics{
+		requests:     make(map[requestKey]uint64),
+		uploadCounts: make([]uint64, len(uploadDurationBuckets)),
+	}
+}
+
+// 统计 mux 中各路由的请求，路由取注册时的模式，避免路径过多
+func (m *serverMetrics) Middleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, route := mux.Handler(r)
+		action, _ := auditAction(r)
+		m.transfer(action, 1)
+		defer m.transfer(action, -1)
+
+		start := time.Now()
+		var in, out int64
+		if r.Body != nil {
+			r.Body = &countingReader{r: r.Body, count: func(n int64) { in += n }}
+		}
+		sw := &statusWriter{countingWriter: countingWriter{ResponseWriter: w, count: func(n int64) { out += n }}}
+		next.ServeHTTP(sw, r)
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.requests[requestKey{route, r.Method, sw.code()}]++
+		m.uploadedBytes += in
+		m.downloadedBytes += out
+		if action == AuditUpload {
+			d := time.Since(start).Seconds()
+			if i, _ := slices.BinarySearch(uploadDurationBuckets, d); i < len(uploadDurationBuckets) {
+				m.uploadCounts[i]++
+			}
+			m.uploadCount++
+			m.uploadSum += d
+		}
+	})
+}
+
+// 调整正在进行的上传或下载数
+func (m *serverMetrics) transfer(action string, delta int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch action {
+	case AuditUpload:
+		m.activeUploads += delta
+	case AuditDownload:
+		m.activeDownloads += delta
+	}
+}
+
+// 以 Prometheus 文本格式输出指标，shares 为各共享文件夹所在磁盘的剩余空间
+func (m *serverMetrics) write(w *bufio.Writer, shares map[string]int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	header := func(name, typ, help string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+
+	header("kuaichuan_http_requests_total", "counter", "HTTP requests by route, method and status code.")
+	keys := slices.SortedFunc(maps.Keys(m.requests), func(a, b requestKey) int {
+		return cmp.Or(strings.Compare(a.route, b.route), strings.Compare(a.method, b.method), a.code-b.code)
+	})
+	for _, k := range keys {
+		fmt.Fprintf(w, "kuaichuan_http_requests_total{route=%s,method=%s,code=\"%d\"} %d\n",
+			labelValue(k.route), labelValue(k.method), k.code, m.requests[k])
+	}
+
+	header("kuaichuan_uploaded_bytes_total", "counter", "Bytes received from clients.")
+	fmt.Fprintf(w, "kuaichuan_uploaded_bytes_total %d\n", m.uploadedBytes)
+	header("kuaichuan_downloaded_bytes_total", "counter", "Bytes sent to clients.")
+	fmt.Fprintf(w, "kuaichuan_downloaded_bytes_total %d\n", m.downloadedBytes)
+
+	header("kuaichuan_active_transfers", "gauge", "Uploads and downloads in progress.")
+	fmt.Fprintf(w, "kuaichuan_active_transfers{direction=\"upload\"} %d\n", m.activeUploads)
+	fmt.Fprintf(w, "kuaichuan_active_transfers{direction=\"download\"} %d\n", m.activeDownloads)
+
+	header("kuaichuan_upload_duration_seconds", "histogram", "Time taken to receive an upload.")
+	var cumulative uint64
+	for i, le := range uploadDurationBuckets {
+		cumulative += m.uploadCounts[i]
+		fmt.Fprintf(w, "kuaichuan_upload_duration_seconds_bucket{le=\"%s\"} %d\n", strconv.FormatFloat(le, 'g', -1, 64), cumulative)
+	}
+	fmt.Fprintf(w, "kuaichuan_upload_duration_seconds_bucket{le=\"+Inf\"} %d\n", m.uploadCount)
+	fmt.Fprintf(w, "kuaichuan_upload_duration_seconds_sum %s\n", strconv.FormatFloat(m.uploadSum, 'g', -1, 64))
+	fmt.Fprintf(w, "kuaichuan_upload_duration_seconds_count %d\n", m.uploadCount)
+
+	header("kuaichuan_share_free_bytes", "gauge", "Free disk space where each share is stored.")
+	for _, share := range slices.Sorted(maps.Keys(shares)) {
+		fmt.Fprintf(w, "kuaichuan_share_free_bytes{share=%s} %d\n", labelValue(share), shares[share])
+	}
+}
+
+// 转义后加上引号的标签值
+func labelValue(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	v = strings.ReplaceAll(v, `"`, `\"`)
+	return `"` + v + `"`
+}
+
+// 各共享文件夹所在磁盘的剩余空间，无法获取的共享不输出
+func (t *AppServer) shareFreeSpace() map[string]int64 {
+	shares := make(map[string]int64)
+	if free, err := t.diskFree(); err == nil {
+		shares[t.UploadDir] = free
+	}
+	return shares
+}
+
+// 指标是否可以访问，没有单独设置认证时使用共享的访问密码
+func (t *AppServer) metricsAuthorized(r *http.Request) bool {
+	if !t.Metrics.protected() && t.Auth.enabled() {
+		return t.Auth.authorized(r)
+	}
+	return t.Metrics.authorized(r)
+}
+
+// 输出 Prometheus 指标
+func (t *AppServer) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if !t.metricsAuthorized(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="metrics"`)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	t.stats.write(bw, t.shareFreeSpace())
+	bw.Flush()
+}
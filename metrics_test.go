@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetricsUseShareAuth(t *testing.T) {
+	s := NewAppServer(t.TempDir())
+	s.Auth = NewAuthConfig("", "pw")
+	s.Metrics = MetricsConfig{Enabled: true}
+	h := s.Handler()
+
+	get := func(user, pass string) int {
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		if pass != "" {
+			req.SetBasicAuth(user, pass)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	// 没有单独设置认证时使用共享的密码
+	if code := get("", ""); code != http.StatusUnauthorized {
+		t.Fatalf("without password: %d", code)
+	}
+	if code := get("x", "pw"); code != http.StatusOK {
+		t.Fatalf("share password: %d", code)
+	}
+
+	// 单独设置后只接受指标的认证
+	s.Metrics.setSecrets("", "tok")
+	if code := get("x", "pw"); code != http.StatusUnauthorized {
+		t.Fatalf("share password with metrics token: %d", code)
+	}
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer tok")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("token: %d", rec.Code)
+	}
+}
+
+func TestConfigHashesMetricsSecrets(t *testing.T) {
+	for _, data := range []string{
+		`{"version": 4, "metrics": {"enabled": true, "username": "prom", "password": "hunter2", "token": "tok123"}}`,
+		`{"version": 5, "metrics": {"enabled": true, "username": "prom", "password": "hunter2", "token": "tok123"}}`,
+	} {
+		c, err := parseConfig([]byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if saved, _ := json.Marshal(c); strings.Contains(string(saved), "hunter2") || strings.Contains(string(saved), "tok123") {
+			t.Fatalf("secrets kept in plain text: %s", saved)
+		}
+
+		basic := httptest.NewRequest("GET", "/metrics", nil)
+		basic.SetBasicAuth("prom", "hunter2")
+		bearer := httptest.NewRequest("GET", "/metrics", nil)
+		bearer.Header.Set("Authorization", "Bearer tok123")
+		wrong := httptest.NewRequest("GET", "/metrics", nil)
+		wrong.SetBasicAuth("prom", "tok123")
+		if !c.Metrics.authorized(basic) || !c.Metrics.authorized(bearer) || c.Metrics.authorized(wrong) {
+			t.Fatalf("metrics auth after loading %s", data)
+		}
+	}
+}
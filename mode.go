@@ -0,0 +1,49 @@
+package main
+
+import "net/http"
+
+// 共享模式，同时作用于网页接口和 WebDAV
+type ShareMode string
+
+const (
+	ModeReadWrite  ShareMode = ""           // 可浏览、下载和上传
+	ModeReadOnly   ShareMode = "readonly"   // 只能浏览和下载
+	ModeUploadOnly ShareMode = "uploadonly" // 只能上传，看不到已有的文件
+)
+
+// 是否可以列出和下载文件
+func (m ShareMode) canRead() bool { return m != ModeUploadOnly }
+
+// 是否可以上传和修改文件
+func (m ShareMode) canWrite() bool { return m != ModeReadOnly }
+
+// 设置共享模式，立即生效
+func (t *AppServer) SetMode(mode ShareMode) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.mode = mode
+}
+
+func (t *AppServer) shareMode() ShareMode {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.mode
+}
+
+// 仅上传模式下拒绝读取，返回是否允许
+func (t *AppServer) checkRead(w http.ResponseWriter, r *http.Request) bool {
+	if !t.shareMode().canRead() {
+		writeError(w, r, http.StatusForbidden, "share_upload_only")
+		return false
+	}
+	return true
+}
+
+// 只读模式下拒绝上传，返回是否允许
+func (t *AppServer) checkWrite(w http.ResponseWriter, r *http.Request) bool {
+	if !t.shareMode().canWrite() {
+		writeError(w, r, http.StatusForbidden, "share_read_only")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"log"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+)
+
+const (
+	// 收到文件后等待的时间，期间收到的其他文件合并为一条通知
+	notifyBatchDelay = 2 * time.Second
+	// 持续上传时最多等待的时间
+	notifyMaxDelay = 10 * time.Second
+)
+
+// 一个收到的文件
+type receivedFile struct {
+	File   FileInfo
+	Device string
+}
+
+// 收到文件时发送桌面通知，连续上传的多个文件合并为一条
+type uploadNotifier struct {
+	mu      sync.Mutex
+	share   string // 收到的文件所在的共享文件夹，非本地存储时为空
+	muted   bool   // 当前共享文件夹已关闭通知
+	pending []receivedFile
+	first   time.Time // 本批第一个文件的时间
+	timer   *time.Timer
+	latest  []receivedFile // 最近一次通知的文件
+}
+
+// 开始共享时设置共享文件夹，share 为空表示文件不在本地，无法在文件管理器中显示
+func (n *uploadNotifier) SetShare(share string) {
+	n.mu.Lock()
+	n.share = share
+	n.mu.Unlock()
+}
+
+// 开启或关闭当前共享文件夹的通知
+func (n *uploadNotifier) SetMuted(muted bool) {
+	n.mu.Lock()
+	n.muted = muted
+	n.mu.Unlock()
+}
+
+// 记录收到的文件，等待一段时间没有新文件后发送通知
+func (n *uploadNotifier) Add(file FileInfo, device string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.muted {
+		return
+	}
+	now := time.Now()
+	if len(n.pending) == 0 {
+		n.first = now
+	}
+	n.pending = append(n.pending, receivedFile{File: file, Device: device})
+	delay := min(notifyBatchDelay, n.first.Add(notifyMaxDelay).Sub(now))
+	if n.timer == nil {
+		n.timer = time.AfterFunc(delay, n.flush)
+	} else {
+		n.timer.Reset(delay)
+	}
+}
+
+// 把等待中的文件合并为一条通知发送
+func (n *uploadNotifier) flush() {
+	n.mu.Lock()
+	files := n.pending
+	n.pending = nil
+	if len(files) > 0 {
+		n.latest = files
+	}
+	n.mu.Unlock()
+	if len(files) == 0 {
+		return
+	}
+
+	var total int64
+	devices := make([]string, 0, 1)
+	for _, f := range files {
+		total += f.File.Size
+		if !slices.Contains(devices, f.Device) {
+			devices = append(devices, f.Device)
+		}
+	}
+	var title, body string
+	if len(files) == 1 {
+		title = tr("ui.notify_file")
+		body = tr("ui.notify_file_body", path.Base(files[0].File.Name), formatFileSize(total))
+	} else {
+		title = tr("ui.notify_files", len(files))
+		body = tr("ui.notify_files_body", path.Base(files[0].File.Name), formatFileSize(total))
+	}
+	if len(devices) == 1 {
+		body += "\n" + tr("ui.notify_from", devices[0])
+	}
+	if note := files[0].File.Note; note != "" {
+		body += "\n" + note
+	}
+	fyne.Do(func() {
+		fyne.CurrentApp().SendNotification(fyne.NewNotification(title, body))
+	})
+}
+
+// 在文件管理器中显示最近一次通知的文件，返回是否有可显示的文件
+func (n *uploadNotifier) RevealLatest() bool {
+	n.mu.Lock()
+	share, files := n.share, n.latest
+	n.mu.Unlock()
+	if share == "" || len(files) == 0 {
+		return false
+	}
+
+	// 一个文件时选中它，多个文件时打开它们共同的文件夹
+	if len(files) == 1 {
+		revealFile(filepath.Join(share, filepath.FromSlash(files[0].File.Name)))
+		return true
+	}
+	dir := path.Dir(files[0].File.Name)
+	for _, f := range files[1:] {
+		if path.Dir(f.File.Name) != dir {
+			dir = "."
+			break
+		}
+	}
+	openFolder(filepath.Join(share, filepath.FromSlash(dir)))
+	return true
+}
+
+// 在文件管理器中选中文件，不支持选中时打开所在的文件夹
+func revealFile(name string) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", "-R", name)
+	case "windows":
+		cmd = exec.Command("explorer", "/select,", name)
+	default:
+		openFolder(filepath.Dir(name))
+		return
+	}
+	// explorer 成功时也会返回非零的退出码
+	if err := cmd.Run(); err != nil && runtime.GOOS != "windows" {
+		log.Printf("无法显示文件: %v", err)
+	}
+}
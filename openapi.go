@@ -0,0 +1,274 @@
+package main
+
+import (
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// 数据结构的 JSON Schema，名称与 apiRoute 中的 Body、Result 对应
+var apiSchemas = map[string]any{
+	"Info": object(map[string]any{
+		"ip":   prop("string", "本机局域网IP"),
+		"port": prop("integer", "端口"),
+		"url":  prop("string", "浏览器访问地址"),
+		"mode": enum("共享模式，空为可读写，readonly 只读，uploadonly 仅上传", "", "readonly", "uploadonly"),
+	}, "ip", "port", "url", "mode"),
+	"FileItem": object(map[string]any{
+		"name": prop("string", "文件名"),
+		"type": enum("file 或 folder", "file", "folder"),
+	}, "name", "type"),
+	"FileList": object(map[string]any{
+		"list": array(ref("FileItem")),
+	}, "list"),
+	"SearchResult": object(map[string]any{
+		"path":    prop("string", "相对共享文件夹的路径"),
+		"name":    prop("string", "文件名"),
+		"type":    enum("file 或 folder", "file", "folder"),
+		"size":    prop("integer", "文件大小，单位字节"),
+		"modTime": prop("string", "修改时间"),
+	}, "path", "name", "type"),
+	"SearchSummary": object(map[string]any{
+		"done":      prop("boolean", "始终为 true"),
+		"count":     prop("integer", "结果数"),
+		"truncated": prop("boolean", "是否达到数量上限"),
+	}, "done", "count", "truncated"),
+	"UploadLimits": object(map[string]any{
+		"maxFileSize":  prop("integer", "单个文件最大大小，0 表示不限制"),
+		"clientQuota":  prop("integer", "每台设备可上传的总大小，0 表示不限制"),
+		"shareQuota":   prop("integer", "共享文件夹总大小上限，0 表示不限制"),
+		"minFreeSpace": prop("integer", "磁盘至少保留的空闲空间"),
+		"preallocate":  prop("boolean", "是否预分配磁盘空间"),
+	}),
+	"Limits": object(map[string]any{
+		"limits":    ref("UploadLimits"),
+		"allowance": prop("integer", "当前设备还可上传的字节数，-1 表示不限制"),
+		"freeSpace": prop("integer", "磁盘剩余空间，-1 表示未知"),
+	}, "limits", "allowance", "freeSpace"),
+	"UploadFile": object(map[string]any{
+		"name": prop("string", "相对共享文件夹的路径"),
+		"size": prop("integer", "文件大小，单位字节"),
+	}, "name", "size"),
+	"UploadRequest": object(map[string]any{
+		"files": array(ref("UploadFile")),
+	}, "files"),
+	"UploadResult": object(map[string]any{
+		"name": prop("string", "保存的文件名"),
+	}, "name"),
+	"Device": object(map[string]any{
+		"id":   prop("string", "设备ID"),
+		"name": prop("string", "设备名称"),
+	}, "id", "name"),
+	"DeviceRename": object(map[string]any{
+		"name": prop("string", "新名称，最多32个字符，为空时恢复默认名称"),
+	}, "name"),
+	"LinkItem": object(map[string]any{
+		"name": prop("string", "文件名"),
+		"type": enum("file 或 folder", "file", "folder"),
+		"size": prop("integer", "文件大小，单位字节"),
+	}, "name", "type", "size"),
+	"LinkInfo": object(map[string]any{
+		"name":         prop("string", "分享的文件或文件夹名称"),
+		"type":         enum("file、folder 或收集链接 request", "file", "folder", "request"),
+		"expires":      prop("string", "过期时间，为空表示不过期"),
+		"remaining":    prop("integer", "剩余下载次数，收集链接为还可上传的文件数，-1 表示不限"),
+		"protected":    prop("boolean", "是否需要密码"),
+		"unlocked":     prop("boolean", "是否已输入正确的密码，不需要密码时为 true"),
+		"size":         prop("integer", "文件链接的文件大小，输入密码后返回"),
+		"list":         array(ref("LinkItem")),
+		"maxFileSize":  prop("integer", "收集链接的单个文件最大大小，0 表示不限制"),
+		"allowedTypes": array(prop("string", "收集链接允许的扩展名或类型，为空表示不限制")),
+	}, "name", "type", "remaining", "protected", "unlocked"),
+	"LinkUnlock": object(map[string]any{
+		"password": prop("string", "链接密码"),
+	}, "password"),
+	"LinkUploadForm": object(map[string]any{
+		"name": prop("string", "上传者姓名"),
+		"note": prop("string", "备注"),
+		"file": map[string]any{"type": "string", "format": "binary"},
+	}, "file"),
+}
+
+func prop(typ, desc string) map[string]any {
+	return map[string]any{"type": typ, "description": desc}
+}
+
+func enum(desc string, values ...string) map[string]any {
+	return map[string]any{"type": "string", "description": desc, "enum": values}
+}
+
+func array(items any) map[string]any {
+	return map[string]any{"type": "array", "items": items}
+}
+
+func ref(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+func object(props map[string]any, required ...string) map[string]any {
+	s := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// 统一格式的成功响应
+func resultSchema(data string) map[string]any {
+	d := any(object(map[string]any{}))
+	if data != "" {
+		d = ref(data)
+	}
+	return object(map[string]any{
+		"ok":   map[string]any{"type": "boolean", "enum": []bool{true}},
+		"data": d,
+	}, "ok", "data")
+}
+
+// 所有错误码，即消息目录中不带前缀的消息ID
+func apiErrorCodes() []string {
+	var codes []string
+	for id := range messagesZH {
+		if !strings.Contains(id, ".") {
+			codes = append(codes, id)
+		}
+	}
+	slices.Sort(codes)
+	return codes
+}
+
+// 根据接口列表生成 OpenAPI 3 文档
+func (t *AppServer) openAPIDoc() map[string]any {
+	schemas := maps.Clone(apiSchemas)
+	schemas["Error"] = object(map[string]any{
+		"ok": map[string]any{"type": "boolean", "enum": []bool{false}},
+		"error": object(map[string]any{
+			"code":    enum("错误码", apiErrorCodes()...),
+			"message": prop("string", "按 Accept-Language 翻译的错误信息"),
+		}, "code", "message"),
+	}, "ok", "error")
+
+	paths := make(map[string]map[string]any)
+	for _, route := range t.apiRoutes() {
+		op := map[string]any{
+			"summary":     route.Summary,
+			"operationId": operationID(route),
+		}
+
+		var params []any
+		for _, m := range pathParamPattern.FindAllStringSubmatch(route.Path, -1) {
+			params = append(params, map[string]any{
+				"name":     m[1],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]any{"type": "string"},
+			})
+		}
+		for _, p := range route.Query {
+			params = append(params, map[string]any{
+				"name":        p.Name,
+				"in":          "query",
+				"required":    p.Required,
+				"description": p.Desc,
+				"schema":      map[string]any{"type": p.Type},
+			})
+		}
+		params = append(params, map[string]any{
+			"name":        "Accept-Language",
+			"in":          "header",
+			"description": "错误信息的语言，支持 zh 和 en",
+			"schema":      map[string]any{"type": "string"},
+		})
+		op["parameters"] = params
+
+		switch route.Body {
+		case "":
+		case "multipart":
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content": map[string]any{
+					"multipart/form-data": map[string]any{
+						"schema": object(map[string]any{
+							"file": map[string]any{"type": "string", "format": "binary"},
+						}, "file"),
+					},
+				},
+			}
+		default:
+			typ := "application/json"
+			if strings.HasSuffix(route.Body, "Form") {
+				typ = "multipart/form-data"
+			}
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{typ: map[string]any{"schema": ref(route.Body)}},
+			}
+		}
+
+		status := route.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		var content map[string]any
+		switch route.Content {
+		case "":
+			content = map[string]any{"application/json": map[string]any{"schema": resultSchema(route.Result)}}
+		case "application/x-ndjson":
+			content = map[string]any{route.Content: map[string]any{"schema": map[string]any{
+				"oneOf": []any{ref("SearchResult"), ref("SearchSummary")},
+			}}}
+		case "application/json":
+			content = map[string]any{route.Content: map[string]any{"schema": map[string]any{"type": "object"}}}
+		default:
+			content = map[string]any{route.Content: map[string]any{"schema": map[string]any{"type": "string", "format": "binary"}}}
+		}
+		responses := map[string]any{
+			strconv.Itoa(status): map[string]any{"description": http.StatusText(status), "content": content},
+		}
+		for _, code := range append(route.Errors, http.StatusMethodNotAllowed) {
+			responses[strconv.Itoa(code)] = map[string]any{
+				"description": http.StatusText(code),
+				"content":     map[string]any{"application/json": map[string]any{"schema": ref("Error")}},
+			}
+		}
+		op["responses"] = responses
+
+		p := apiV1Prefix + route.Path
+		if paths[p] == nil {
+			paths[p] = make(map[string]any)
+		}
+		paths[p][strings.ToLower(route.Method)] = op
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "快传 API",
+			"version":     "1.0.0",
+			"description": "局域网文件共享接口。成功时返回 {ok: true, data}，失败时返回 {ok: false, error: {code, message}}。",
+		},
+		"servers":    []any{map[string]any{"url": t.BaseURL()}},
+		"paths":      paths,
+		"components": map[string]any{"schemas": schemas},
+	}
+}
+
+// 路径中的参数，如 /links/{token}
+var pathParamPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+// 由方法和路径生成的操作ID，如 getFiles、postUploadRequest、getLinksTokenDownload
+func operationID(route apiRoute) string {
+	id := strings.ToLower(route.Method)
+	for _, part := range strings.FieldsFunc(route.Path, func(r rune) bool { return strings.ContainsRune("/.{}", r) }) {
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return id
+}
+
+// 返回 OpenAPI 文档
+func (t *AppServer) openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, t.openAPIDoc())
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package main
+
+import "os"
+
+// 预先为文件分配磁盘空间，不支持 fallocate 的平台直接扩展文件长度
+func preallocate(f *os.File, size int64) error {
+	return f.Truncate(size)
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/mozillazg/go-pinyin"
+)
+
+// 默认及最大返回的搜索结果数
+const (
+	defaultSearchLimit = 200
+	maxSearchLimit     = 2000
+)
+
+// 搜索结果
+type SearchResult struct {
+	Path    string `json:"path"` // 相对共享文件夹的路径
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Size    int64  `json:"size"`
+	ModTime string `json:"modTime"`
+}
+
+// 文件名匹配器
+type nameMatcher struct {
+	query string
+	glob  bool
+}
+
+func newNameMatcher(q string) nameMatcher {
+	q = strings.ToLower(strings.TrimSpace(q))
+	return nameMatcher{query: q, glob: strings.ContainsAny(q, "*?[")}
+}
+
+// 判断文件名是否匹配，支持通配符、子串以及中文名称的拼音和拼音首字母
+func (m nameMatcher) match(name string) bool {
+	lower := strings.ToLower(name)
+	if m.glob {
+		ok, _ := path.Match(m.query, lower)
+		return ok
+	}
+	if strings.Contains(lower, m.query) {
+		return true
+	}
+	if !hasHan(name) {
+		return false
+	}
+	query := strings.ReplaceAll(m.query, " ", "")
+	full, initials := namePinyin(lower)
+	return strings.Contains(full, query) || strings.Contains(initials, query)
+}
+
+// 是否包含汉字
+func hasHan(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// 保留非汉字字符
+func keepRune(r rune, a pinyin.Args) []string {
+	return []string{string(r)}
+}
+
+// 名称的全拼和拼音首字母，非汉字字符保持不变
+func namePinyin(name string) (string, string) {
+	args := pinyin.NewArgs()
+	args.Fallback = keepRune
+	full := strings.Join(pinyin.LazyPinyin(name, args), "")
+
+	args.Style = pinyin.FirstLetter
+	initials := strings.Join(pinyin.LazyPinyin(name, args), "")
+	return full, initials
+}
+
+// 搜索处理函数，递归查找文件名并逐条返回结果（每行一个JSON），客户端断开后停止搜索
+func (t *AppServer) searchHandler(w http.ResponseWriter, r *http.Request) {
+	if !t.checkRead(w, r) {
+		return
+	}
+	query := r.URL.Query()
+	q := query.Get("q")
+	if strings.TrimSpace(q) == "" {
+		writeError(w, r, http.StatusBadRequest, "search_missing_query")
+		return
+	}
+	limit := defaultSearchLimit
+	if n, err := strconv.Atoi(query.Get("limit")); err == nil && n > 0 {
+		limit = min(n, maxSearchLimit)
+	}
+
+	base := cleanRelPath(query.Get("path"))
+	if t.isHidden(base, true) {
+		writeError(w, r, http.StatusNotFound, "dir_not_found")
+		return
+	}
+	share, err := t.storage()
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, "dir_not_found")
+		return
+	}
+	matcher := newNameMatcher(q)
+	ctx := r.Context()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+
+	count := 0
+	truncated := false      // 达到数量上限后又找到了结果
+	var lastFlush time.Time // 第一条结果立即推送
+	share.Walk(base, func(rel string, info fs.FileInfo) error {
+		if ctx.Err() != nil {
+			return fs.SkipAll
+		}
+		if t.isHidden(rel, info.IsDir()) {
+			if info.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !matcher.match(info.Name()) {
+			return nil
+		}
+		if count >= limit {
+			truncated = true
+			return fs.SkipAll
+		}
+
+		result := SearchResult{
+			Path:    rel,
+			Name:    info.Name(),
+			Type:    "file",
+			ModTime: info.ModTime().Format("2006-01-02 15:04:05"),
+		}
+		if info.IsDir() {
+			result.Type = "folder"
+		} else {
+			result.Size = info.Size()
+		}
+		if err := enc.Encode(result); err != nil {
+			return fs.SkipAll
+		}
+
+		count++
+		// 定期把结果推送给客户端
+		if flusher != nil && time.Since(lastFlush) > 200*time.Millisecond {
+			flusher.Flush()
+			lastFlush = time.Now()
+		}
+		return nil
+	})
+	if ctx.Err() != nil {
+		return
+	}
+
+	enc.Encode(map[string]any{
+		"done":      true,
+		"count":     count,
+		"truncated": truncated,
+	})
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSearchTruncated(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a1.txt", "a2.txt", "a3.txt"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+	s := NewAppServer(dir)
+
+	// 最后一行为统计，结果恰好等于上限时没有被截断
+	done := func(limit string) map[string]any {
+		rec := httptest.NewRecorder()
+		s.searchHandler(rec, httptest.NewRequest("GET", "/api/search?q=a&limit="+limit, nil))
+		lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+		var m map[string]any
+		json.Unmarshal([]byte(lines[len(lines)-1]), &m)
+		return m
+	}
+	if m := done("3"); m["count"] != 3.0 || m["truncated"] != false {
+		t.Fatalf("limit 3: %v", m)
+	}
+	if m := done("2"); m["count"] != 2.0 || m["truncated"] != true {
+		t.Fatalf("limit 2: %v", m)
+	}
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime/multipart"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// 单个文件上传失败后的最大重试次数
+const sendRetries = 3
+
+// 发送进度
+type SendProgress struct {
+	File      string // 当前文件
+	FileIndex int    // 当前文件序号，从 0 开始
+	FileCount int
+	Sent      int64 // 所有文件已发送的字节数
+	Total     int64
+}
+
+// 待发送的文件
+type sendFile struct {
+	Path string // 本地路径
+	Dir  string // 对方共享文件夹中的目标目录
+	Name string
+	Size int64
+}
+
+// 对方拒绝或限制导致的错误，不需要重试
+type sendRejected struct {
+	Status  int
+	Message string
+}
+
+func (e *sendRejected) Error() string {
+	return e.Message
+}
+
+// 对方设置了访问密码，需要输入正确的账号密码
+func isSendUnauthorized(err error) bool {
+	var rejected *sendRejected
+	return errors.As(err, &rejected) && rejected.Status == http.StatusUnauthorized
+}
+
+// 发送到另一台快传的客户端
+type Sender struct {
+	Addr     string // 对方地址 host:port
+	Name     string // 本机名称，显示在对方的确认弹窗和设备列表中
+	Username string // 对方设置了访问密码时使用的账号密码
+	Password string
+	Progress func(SendProgress)
+
+	client *http.Client
+}
+
+func NewSender(addr, name string) *Sender {
+	jar, _ := cookiejar.New(nil)
+	return &Sender{
+		Addr:   addr,
+		Name:   name,
+		client: &http.Client{Jar: jar},
+	}
+}
+
+// 发送文件或文件夹，文件夹保持原有的目录结构
+func (s *Sender) Send(ctx context.Context, paths []string) error {
+	files, err := collectSendFiles(paths)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.New("没有可发送的文件")
+	}
+
+	// 设置本机名称，获取设备 cookie
+	if err := s.post(ctx, "/api/device", map[string]string{"name": s.Name}); err != nil {
+		return err
+	}
+
+	// 请求对方确认
+	request := make([]UploadFile, len(files))
+	var total int64
+	for i, f := range files {
+		request[i] = UploadFile{Name: path.Join(f.Dir, f.Name), Size: f.Size}
+		total += f.Size
+	}
+	if err := s.post(ctx, "/api/upload/request", map[string]any{"files": request}); err != nil {
+		return err
+	}
+
+	progress := SendProgress{FileCount: len(files), Total: total}
+	for i, f := range files {
+		progress.File = f.Name
+		progress.FileIndex = i
+		sent, err := s.sendWithRetry(ctx, f, progress)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+		progress.Sent += sent
+	}
+	s.report(progress)
+	return nil
+}
+
+// 上传单个文件，网络错误时重试
+func (s *Sender) sendWithRetry(ctx context.Context, f sendFile, progress SendProgress) (int64, error) {
+	var err error
+	for attempt := 0; attempt < sendRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(time.Duration(1<<(attempt-1)) * time.Second):
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			}
+		}
+		err = s.sendFile(ctx, f, progress)
+		var rejected *sendRejected
+		if err == nil {
+			return f.Size, nil
+		}
+		if errors.As(err, &rejected) || ctx.Err() != nil {
+			return 0, err
+		}
+	}
+	return 0, err
+}
+
+// 以 multipart 表单上传文件，提前计算请求长度以便对方检查空间
+func (s *Sender) sendFile(ctx context.Context, f sendFile, progress SendProgress) error {
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	if _, err := mw.CreateFormFile("file", f.Name); err != nil {
+		return err
+	}
+	head := append([]byte(nil), buf.Bytes()...)
+	buf.Reset()
+	mw.Close()
+	tail := buf.Bytes()
+
+	content := &progressReader{r: file, report: func(n int64) {
+		p := progress
+		p.Sent += n
+		s.report(p)
+	}}
+	body := io.MultiReader(bytes.NewReader(head), content, bytes.NewReader(tail))
+
+	u := url.URL{Scheme: "http", Host: s.Addr, Path: "/api/upload", RawQuery: url.Values{"path": {f.Dir}}.Encode()}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(head)) + f.Size + int64(len(tail))
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("User-Agent", "kuaichuan ("+s.Name+")")
+	s.authorize(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return responseError(resp)
+}
+
+// 发送JSON请求，使用旧接口以兼容旧版本的快传
+func (s *Sender) post(ctx context.Context, p string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://"+s.Addr+p, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "kuaichuan ("+s.Name+")")
+	s.authorize(req)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return responseError(resp)
+}
+
+// 设置了密码时使用 Basic 认证
+func (s *Sender) authorize(req *http.Request) {
+	if s.Password != "" {
+		req.SetBasicAuth(s.Username, s.Password)
+	}
+}
+
+func (s *Sender) report(p SendProgress) {
+	if s.Progress != nil {
+		s.Progress(p)
+	}
+}
+
+// 将对方返回的错误转换为 error，4xx 错误不再重试
+func responseError(resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	var body struct {
+		Message string `json:"message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	message := resp.Status
+	if json.Unmarshal(data, &body) == nil && body.Message != "" {
+		message = body.Message
+	}
+	if resp.StatusCode < 500 || resp.StatusCode == http.StatusInsufficientStorage {
+		return &sendRejected{Status: resp.StatusCode, Message: message}
+	}
+	return errors.New(message)
+}
+
+// 展开待发送的文件和文件夹
+func collectSendFiles(paths []string) ([]sendFile, error) {
+	var files []sendFile
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, sendFile{Path: p, Name: info.Name(), Size: info.Size()})
+			continue
+		}
+
+		parent := filepath.Dir(p)
+		err = filepath.WalkDir(p, func(fp string, d fs.DirEntry, err error) error {
+			if err != nil || !d.Type().IsRegular() {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(parent, filepath.Dir(fp))
+			if err != nil {
+				return err
+			}
+			files = append(files, sendFile{Path: fp, Dir: filepath.ToSlash(rel), Name: d.Name(), Size: info.Size()})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// 统计读取进度
+type progressReader struct {
+	r      io.Reader
+	n      int64
+	report func(int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.n += int64(n)
+	p.report(p.n)
+	return n, err
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSendBasicAuth(t *testing.T) {
+	dst := t.TempDir()
+	s := NewAppServer(dst)
+	s.Auth = NewAuthConfig("me", "secret")
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+	addr := srv.Listener.Addr().String()
+
+	file := filepath.Join(t.TempDir(), "a.txt")
+	os.WriteFile(file, []byte("hello"), 0644)
+
+	// 没有密码时返回 401，由界面询问密码
+	if err := NewSender(addr, "test").Send(context.Background(), []string{file}); !isSendUnauthorized(err) {
+		t.Fatalf("without password: %v", err)
+	}
+	sender := NewSender(addr, "test")
+	sender.Username, sender.Password = "me", "wrong"
+	if err := sender.Send(context.Background(), []string{file}); !isSendUnauthorized(err) {
+		t.Fatalf("wrong password: %v", err)
+	}
+
+	sender.Password = "secret"
+	if err := sender.Send(context.Background(), []string{file}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "a.txt")); string(data) != "hello" {
+		t.Fatalf("received %q", data)
+	}
+}
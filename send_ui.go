@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 选择局域网中的其他快传并发送文件
+func showSendDialog(window fyne.Window, state *AppState) {
+	var peers []Peer
+	selected := ""
+
+	addrEntry := widget.NewEntry()
+	addrEntry.SetPlaceHolder(tr("ui.send_addr_placeholder"))
+
+	list := widget.NewList(
+		func() int {
+			return len(peers)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel(tr("ui.device_name"))
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			o.(*widget.Label).SetText(fmt.Sprintf("%s  (%s)", peers[i].Name, peers[i].Addr))
+		},
+	)
+	list.OnSelected = func(i widget.ListItemID) {
+		selected = peers[i].Addr
+		addrEntry.SetText(selected)
+	}
+
+	empty := widget.NewLabel(tr("ui.send_searching"))
+	refresh := func() {
+		peers = state.Discovery.Peers()
+		if len(peers) == 0 {
+			empty.Show()
+		} else {
+			empty.Hide()
+		}
+		list.Refresh()
+	}
+	refresh()
+
+	// 对话框打开期间定时刷新
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(announceInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				fyne.Do(refresh)
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	var d dialog.Dialog
+	target := func() (string, bool) {
+		addr := strings.TrimSpace(addrEntry.Text)
+		if addr == "" {
+			dialog.ShowError(errors.New(tr("ui.send_target_required")), window)
+			return "", false
+		}
+		if !strings.Contains(addr, ":") {
+			addr += ":" + strconv.Itoa(defaultPort)
+		}
+		return addr, true
+	}
+	sendFileBtn := widget.NewButton(tr("ui.send_file"), func() {
+		addr, ok := target()
+		if !ok {
+			return
+		}
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			reader.Close()
+			d.Hide()
+			startSend(window, addr, []string{reader.URI().Path()}, "", "")
+		}, window)
+	})
+	sendFolderBtn := widget.NewButton(tr("ui.send_folder"), func() {
+		addr, ok := target()
+		if !ok {
+			return
+		}
+		dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+			if err != nil || uri == nil {
+				return
+			}
+			d.Hide()
+			startSend(window, addr, []string{uri.Path()}, "", "")
+		}, window)
+	})
+
+	content := container.NewBorder(
+		empty,
+		container.NewVBox(addrEntry, container.NewHBox(sendFileBtn, sendFolderBtn)),
+		nil, nil,
+		list,
+	)
+	d = dialog.NewCustom(tr("ui.send_to_other"), tr("ui.close"), content, window)
+	d.SetOnClosed(func() { close(stop) })
+	d.Resize(fyne.NewSize(480, 400))
+	d.Show()
+}
+
+// 开始发送并显示进度，对方要求登录时询问账号密码后重新发送
+func startSend(window fyne.Window, addr string, paths []string, username, password string) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	status := widget.NewLabel(tr("ui.send_waiting"))
+	bar := widget.NewProgressBar()
+	d := dialog.NewCustom(tr("ui.sending"), tr("ui.cancel"), container.NewVBox(status, bar), window)
+	d.SetOnClosed(cancel)
+	d.Resize(fyne.NewSize(400, 150))
+	d.Show()
+
+	sender := NewSender(addr, hostName())
+	sender.Username, sender.Password = username, password
+	var lastUpdate time.Time
+	sender.Progress = func(p SendProgress) {
+		// 限制界面刷新频率
+		if time.Since(lastUpdate) < 100*time.Millisecond && p.Sent < p.Total {
+			return
+		}
+		lastUpdate = time.Now()
+		fyne.Do(func() {
+			status.SetText(fmt.Sprintf("(%d/%d) %s", p.FileIndex+1, p.FileCount, p.File))
+			if p.Total > 0 {
+				bar.SetValue(float64(p.Sent) / float64(p.Total))
+			}
+		})
+	}
+
+	go func() {
+		err := sender.Send(ctx, paths)
+		fyne.Do(func() {
+			d.Hide()
+			switch {
+			case errors.Is(err, context.Canceled):
+			case isSendUnauthorized(err):
+				showSendLogin(window, addr, paths, username, password != "")
+			case err != nil:
+				dialog.ShowError(fmt.Errorf("%s: %w", tr("ui.send_failed"), err), window)
+			default:
+				dialog.ShowInformation(tr("ui.send_done"), tr("ui.send_done_detail", addr), window)
+			}
+		})
+	}()
+}
+
+// 询问对方的访问密码，retry 表示上次输入的密码不正确
+func showSendLogin(window fyne.Window, addr string, paths []string, username string, retry bool) {
+	userEntry := widget.NewEntry()
+	userEntry.SetText(username)
+	passEntry := widget.NewPasswordEntry()
+	message := tr("ui.send_login_message", addr)
+	if retry {
+		message = tr("ui.send_login_wrong")
+	}
+	items := []*widget.FormItem{
+		widget.NewFormItem("", widget.NewLabel(message)),
+		widget.NewFormItem(tr("ui.settings_auth_user"), userEntry),
+		widget.NewFormItem(tr("ui.settings_auth_password"), passEntry),
+	}
+	d := dialog.NewForm(tr("ui.send_login"), tr("ui.send_login_ok"), tr("ui.cancel"), items, func(ok bool) {
+		if ok && passEntry.Text != "" {
+			startSend(window, addr, paths, strings.TrimSpace(userEntry.Text), passEntry.Text)
+		}
+	}, window)
+	d.Resize(fyne.NewSize(400, 0))
+	d.Show()
+	window.Canvas().Focus(passEntry)
+}
@@ -3,15 +3,20 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"log"
+	"mime"
 	"net"
 	"net/http"
-	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -20,6 +25,10 @@ type FileInfo struct {
 	Name       string `json:"name"`
 	Size       int64  `json:"size"`
 	UploadedAt string `json:"uploaded_at"`
+	Uploader   string `json:"uploader,omitempty"` // 通过收集链接上传时填写的姓名
+	Note       string `json:"note,omitempty"`     // 通过收集链接上传时填写的备注
+	Link       string `json:"link,omitempty"`     // 收集链接的 token
+	Device     string `json:"device,omitempty"`   // 上传的设备
 }
 
 type FileItem struct {
@@ -27,87 +36,155 @@ type FileItem struct {
 	Type string `json:"type"`
 }
 
-// 配置
-const (
-	port = ":8000"
-)
-
-var server *http.Server
+// 默认监听的端口
+const defaultPort = 8000
 
 type AppServer struct {
-	UploadDir string
+	UploadDir      string
+	Port           int        // 监听的端口，为 0 时使用 defaultPort
+	BindAddress    string     // 监听的地址，为空时监听所有网卡
+	Auth           AuthConfig // 访问共享需要的账号密码
+	FollowSymlinks bool       // 是否跟随共享文件夹内的符号链接
+	Storage        Storage    // 共享文件夹的存储，未设置时使用本地的 UploadDir
+	Limits         UploadLimits
+	Throttle       *Throttle
+	Devices        *DeviceRegistry
+	Links          *LinkRegistry // 分享链接，为空时 /s/ 下的链接都不可用
+	CORS           CORSConfig    // /api/v1 的跨域访问配置
+	Audit          *AuditLog     // 访问记录，为空时不记录
+	Metrics        MetricsConfig // /metrics 的配置
+
+	// 收到需要确认的上传请求时调用，桌面端通过 decide 返回结果，ctx 结束表示已超时
+	OnApprovalRequest func(ctx context.Context, req UploadRequest, decide func(allowed bool))
+
+	// 文件上传完成后调用，device 为上传设备的名称，可能在任意 goroutine 中调用
+	OnFileReceived func(file FileInfo, device string)
+
+	ignore ignoreCache    // 忽略规则
+	stats  *serverMetrics // 开启指标时的运行统计
+	events eventHub       // 推送给浏览器的事件
+	names  sync.Mutex     // 不覆盖已有文件的上传在选择文件名和改名期间持有
+
+	mu              sync.Mutex
+	clientUsage     map[string]int64 // 各客户端已上传的字节数
+	requireApproval bool
+	mode            ShareMode                   // 共享模式
+	approved        map[string]bool             // 已同意的设备和IP
+	pending         map[string]*pendingApproval // 等待确认的设备
+	srv             *http.Server
+	transfers       map[int64]*activeTransfer // 正在进行的传输
+	lastTransfer    int64
 }
 
 func NewAppServer(uploadDir string) *AppServer {
 	s := &AppServer{
 		UploadDir: uploadDir,
+		Devices:   NewDeviceRegistry(),
 	}
 	return s
 }
 
-func (t *AppServer) StopServer() {
-	if server != nil {
-		log.Println("正在关闭服务器...")
-		if err := server.Shutdown(context.Background()); err != nil {
-			log.Printf("关闭服务器失败: %v", err)
-		}
-		log.Println("服务器已关闭")
-	}
-}
-
-func (t *AppServer) StartServer() {
-
+// 所有路由及中间件
+func (t *AppServer) Handler() http.Handler {
 	mux := http.NewServeMux()
 	// 注册路由
 	mux.HandleFunc("/", t.serveIndex)
 	mux.HandleFunc("/get-ip", t.getIPHandler)
 	mux.HandleFunc("/api/upload", t.uploadHandler)
+	mux.HandleFunc("/api/upload/request", t.uploadRequestHandler)
 	mux.HandleFunc("/download/", t.downloadHandler)
 	mux.HandleFunc("/upload", t.upload)
 	mux.HandleFunc("/api/files", t.getFileList)
+	mux.HandleFunc("/api/search", t.searchHandler)
+	mux.HandleFunc("/api/limits", t.limitsHandler)
+	mux.HandleFunc("/api/device", t.deviceHandler)
+	mux.HandleFunc(linkPrefix+"{token}", t.linkPage)
+	mux.Handle("/assets/", webAssets())
+	mux.Handle(davPrefix+"/", t.davHandler())
+	t.registerAPIv1(mux)
 	// mux.HandleFunc("/delete/", deleteHandler)
 	// mux.HandleFunc("/delete-all", deleteAllHandler)
 	// mux.HandleFunc("/history", historyHandler)
-	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("."))))
 
-	// 启动服务器
-	log.Printf("服务器运行在端口 %s", port)
-	log.Printf("访问地址: http://%s%s", t.GetLocalIP(), port)
+	handler := t.trackTransfers(t.auditMiddleware(mux))
+	if t.Metrics.Enabled {
+		t.stats = newServerMetrics()
+		handler = t.stats.Middleware(mux, handler)
+	}
+	if t.Throttle != nil {
+		handler = t.Throttle.Middleware(handler)
+	}
+	handler = t.Auth.Middleware(t.Devices.Middleware(handler))
+	if !t.Metrics.Enabled {
+		return handler
+	}
+
+	// 抓取指标的程序不算作设备，也不受限速影响
+	root := http.NewServeMux()
+	root.HandleFunc("/metrics", t.metricsHandler)
+	root.Handle("/", handler)
+	return root
+}
 
-	server = &http.Server{
-		Addr:    ":8000",
-		Handler: mux,
+func (t *AppServer) StartServer() error {
+	// 先监听端口，端口被占用等错误直接返回
+	addr := net.JoinHostPort(t.BindAddress, strconv.Itoa(t.port()))
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
 	}
+	log.Printf("服务器启动在 %s", addr)
+	log.Printf("访问地址: %s", t.BaseURL())
 
-	log.Println("服务器启动在 :8000")
+	server := &http.Server{
+		Handler:     t.Handler(),
+		ConnContext: saveConnInContext,
+	}
+	t.mu.Lock()
+	t.srv = server
+	t.mu.Unlock()
 
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		log.Fatalf("服务器启动失败: %v", err)
+	go func() {
+		if err := server.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Printf("服务器异常退出: %v", err)
+		}
+	}()
+	return nil
+}
+
+// 监听的端口
+func (t *AppServer) port() int {
+	if t.Port == 0 {
+		return defaultPort
 	}
+	return t.Port
+}
+
+// 其他设备访问本机的地址，如 http://192.168.1.2:8000
+func (t *AppServer) BaseURL() string {
+	return "http://" + net.JoinHostPort(t.GetLocalIP(), strconv.Itoa(t.port()))
 }
 
-// 首页处理函数
+// 首页处理函数，仅上传模式下转到上传页面
 func (t *AppServer) serveIndex(w http.ResponseWriter, r *http.Request) {
-	// 写入响应内容
-	if _, err := w.Write([]byte(resourceListHtml.StaticContent)); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("Internal Server Error"))
+	if !t.shareMode().canRead() {
+		http.Redirect(w, r, "/upload", http.StatusFound)
+		return
 	}
-	// http.ServeFile(w, r, filepath.Join("static", "list.html"))
+	webAssets().servePage(w, r, "list.html")
 }
 
-// 首页处理函数
+// 上传页面处理函数
 func (t *AppServer) upload(w http.ResponseWriter, r *http.Request) {
-	// http.ServeFile(w, r, filepath.Join("static", "upload.html"))
-	// 写入响应内容
-	if _, err := w.Write([]byte(resourceUploadHtml.StaticContent)); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte("Internal Server Error"))
-	}
+	webAssets().servePage(w, r, "upload.html")
 }
 
 // 获取本地IP地址
 func (t *AppServer) GetLocalIP() string {
+	// 只监听一个地址时使用该地址
+	if ip := net.ParseIP(t.BindAddress); ip != nil && !ip.IsUnspecified() {
+		return ip.String()
+	}
 	addrs, err := net.InterfaceAddrs()
 	if err != nil {
 		return "127.0.0.1"
@@ -134,67 +211,208 @@ func (t *AppServer) getIPHandler(w http.ResponseWriter, r *http.Request) {
 // 文件上传处理函数
 func (t *AppServer) uploadHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "方法不允许", http.StatusMethodNotAllowed)
+		writeError(w, r, http.StatusMethodNotAllowed, "method_not_allowed")
+		return
+	}
+	if !t.checkWrite(w, r) {
 		return
 	}
 
-	// 解析表单数据
-	if err := r.ParseMultipartForm(32 << 20); err != nil { // 32MB
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	// 根据请求声明的大小预先检查限制，避免接收完整个文件后才失败
+	ip := clientIP(r)
+	allowance := t.uploadAllowance(ip)
+	if r.ContentLength > 0 && !allowance.allows(r.ContentLength-multipartSlack) {
+		writeError(w, r, allowance.Status, allowance.Error, allowance.Args...)
 		return
 	}
 
-	// 获取文件
-	file, handler, err := r.FormFile("file")
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	// 以流的方式读取表单，文件内容直接写入共享文件夹
+	part, _, id := readUploadForm(r)
+	if id != "" {
+		writeError(w, r, http.StatusBadRequest, id)
 		return
 	}
-	defer file.Close()
+	defer part.Close()
 
 	// 安全处理文件名，防止路径遍历攻击
-	filename := filepath.Base(handler.Filename)
+	filename := filepath.Base(part.FileName())
 	safeFilename := t.sanitizeFilename(filename)
-	dstPath := filepath.Join(t.UploadDir, safeFilename)
-
-	// 创建保存文件
-	dst, err := os.Create(dstPath)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	relDir := cleanRelPath(r.URL.Query().Get("path"))
+	if e := auditEntry(r); e != nil {
+		e.Path = "/" + path.Join(relDir, safeFilename)
+	}
+	if t.isHidden(path.Join(relDir, safeFilename), false) {
+		writeError(w, r, http.StatusForbidden, "upload_forbidden_path")
 		return
 	}
-	defer dst.Close()
 
-	// 复制文件内容
-	if _, err := io.Copy(dst, file); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	// 需要确认时等待桌面端同意
+	result := t.requestApproval(r.Context(), t.newUploadRequest(r, []UploadFile{{Name: path.Join(relDir, safeFilename), Size: r.ContentLength}}))
+	if !writeApprovalResult(w, r, result) {
 		return
 	}
 
-	// 获取文件大小
-	fileInfo, err := os.Stat(dstPath)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	// 保存到指定的子目录
+	dstPath := path.Join(relDir, safeFilename)
+	_, written, ok := t.saveUpload(w, r, part, dstPath, allowance, false)
+	if !ok {
 		return
 	}
 
 	// 更新上传历史
 	fileInfoItem := FileInfo{
-		Name:       safeFilename,
-		Size:       fileInfo.Size(),
+		Name:       path.Join(relDir, safeFilename),
+		Size:       written,
 		UploadedAt: time.Now().Format("2006-01-02 15:04:05"),
 	}
-	if err := t.updateHistory(fileInfoItem); err != nil {
-		log.Printf("更新历史记录失败: %v", err)
-		// 即使历史记录更新失败，也返回成功状态
-	}
+	// 即使历史记录更新失败，也返回成功状态
+	t.fileReceived(fileInfoItem, t.deviceName(r))
 
 	// 返回成功响应
+	writeResult(w, r, http.StatusCreated, T(requestLang(r), "msg.upload_ok"), map[string]any{
+		"name": safeFilename,
+	})
+}
+
+// 把上传的内容保存到 dstPath，先写入同一目录的临时文件，完成后再替换原文件；
+// unique 时不替换，已有同名文件时改用 uniqueFilePath 选出的名称。
+// 返回保存的路径，超出额度或失败时删除临时文件并返回错误响应，原有的同名文件不受影响
+func (t *AppServer) saveUpload(w http.ResponseWriter, r *http.Request, src io.Reader, dstPath string, allowance uploadAllowance, unique bool) (string, int64, bool) {
+	share, err := t.storage()
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "storage_unavailable")
+		return "", 0, false
+	}
+	dir := path.Dir(dstPath)
+	if dir == "." {
+		dir = ""
+	}
+	if err := share.MkdirAll(dir); err != nil {
+		writeError(w, r, http.StatusInternalServerError, "upload_failed", err)
+		return "", 0, false
+	}
+	if info, err := share.Stat(dstPath); err == nil && info.IsDir() && !unique {
+		writeError(w, r, http.StatusConflict, "upload_target_is_dir")
+		return "", 0, false
+	}
+
+	// 创建临时文件
+	tmpPath := uploadPartName(dstPath)
+	dst, err := share.Create(tmpPath)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "upload_failed", err)
+		return "", 0, false
+	}
+	defer dst.Close()
+
+	// 读取时预留客户端额度，失败时退回
+	quota := &quotaReader{r: src, t: t, ip: clientIP(r)}
+	fail := func(status int, id string, args ...any) (string, int64, bool) {
+		dst.Close()
+		share.Remove(tmpPath)
+		quota.release()
+		writeError(w, r, status, id, args...)
+		return "", 0, false
+	}
+
+	// 本地存储可以预分配磁盘空间
+	file, _ := dst.(*os.File)
+	if file != nil && t.Limits.Preallocate && r.ContentLength > 0 {
+		if err := preallocate(file, r.ContentLength); err != nil {
+			log.Printf("预分配磁盘空间失败: %v", err)
+		}
+	}
+
+	// 复制文件内容，超出额度时立即停止接收
+	src = quota
+	if allowance.Bytes >= 0 {
+		src = io.LimitReader(src, allowance.Bytes+1)
+	}
+	written, err := io.Copy(dst, src)
+	if quota.exceeded {
+		return fail(http.StatusRequestEntityTooLarge, "limit_client_quota", formatFileSize(t.Limits.ClientQuota))
+	}
+	if err == nil && !allowance.allows(written) {
+		return fail(allowance.Status, allowance.Error, allowance.Args...)
+	}
+	if err != nil {
+		return fail(http.StatusInternalServerError, "upload_failed", err)
+	}
+
+	// 去掉预分配多出的空间
+	if file != nil {
+		if err := file.Truncate(written); err != nil {
+			return fail(http.StatusInternalServerError, "upload_failed", err)
+		}
+	}
+	if err := dst.Close(); err != nil {
+		return fail(http.StatusInternalServerError, "upload_failed", err)
+	}
+
+	if unique {
+		dstPath, err = t.renameUnique(share, tmpPath, dstPath)
+	} else {
+		err = replacePart(share, tmpPath, dstPath)
+	}
+	if errors.Is(err, errTargetIsDir) {
+		return fail(http.StatusConflict, "upload_target_is_dir")
+	} else if err != nil {
+		return fail(http.StatusInternalServerError, "upload_failed", err)
+	}
+	auditUpload(r, dstPath, written)
+	return dstPath, written, true
+}
+
+// 把临时文件改名为不与已有文件重名的路径，选择和改名之间持有锁，同时上传的同名文件不会互相覆盖
+func (t *AppServer) renameUnique(share Storage, part, rel string) (string, error) {
+	t.names.Lock()
+	defer t.names.Unlock()
+	dst := uniqueFilePath(share, rel)
+	return dst, share.Rename(part, dst)
+}
+
+var errTargetIsDir = errors.New("同名的文件夹已存在")
+
+// 把写完的临时文件改名为 dst，替换同名文件，不允许覆盖的存储先删除原文件；
+// dst 是目录时不删除，返回 errTargetIsDir
+func replacePart(share Storage, part, dst string) error {
+	err := share.Rename(part, dst)
+	if !errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	if info, statErr := share.Stat(dst); statErr == nil && info.IsDir() {
+		return errTargetIsDir
+	}
+	share.Remove(dst)
+	return share.Rename(part, dst)
+}
+
+// 返回JSON格式的响应
+func writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]any{
-		"message": "文件上传成功",
-		"name":    safeFilename,
-		"code":    200,
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// 返回JSON格式的错误信息，message 按请求的语言翻译，上传页面会直接显示；
+// id 为固定的错误码，供程序判断
+func writeError(w http.ResponseWriter, r *http.Request, status int, id string, args ...any) {
+	w.Header().Add("Vary", "Accept-Language")
+	if e := auditEntry(r); e != nil {
+		e.Result = id
+	}
+	message := T(requestLang(r), id, args...)
+	if isAPIv1(r) {
+		writeJSON(w, status, map[string]any{
+			"ok":    false,
+			"error": map[string]any{"code": id, "message": message},
+		})
+		return
+	}
+	writeJSON(w, status, map[string]any{
+		"message": message,
+		"error":   id,
+		"code":    status,
 	})
 }
 
@@ -206,27 +424,45 @@ func (t *AppServer) downloadHandler(w http.ResponseWriter, r *http.Request) {
 	// 获取文件名
 	filename := path
 	if filename == "" {
-		http.Error(w, "缺少文件名", http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, "missing_filename")
+		return
+	}
+	if !t.checkRead(w, r) {
 		return
 	}
 
 	// 安全处理文件名，防止路径遍历攻击
 	// safeFilename := t.sanitizeFilename(filename)
-	safeFilename, _ := url.QueryUnescape(filename)
-	filePath := filepath.Join(t.UploadDir, safeFilename)
+	rel := cleanRelPath(filename)
+	if t.isHidden(rel, false) {
+		writeError(w, r, http.StatusNotFound, "file_not_found")
+		return
+	}
 
-	// 检查文件是否存在
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		http.Error(w, "文件不存在", http.StatusNotFound)
+	// 检查文件是否存在，只能打开共享文件夹内的文件
+	share, err := t.storage()
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, "file_not_found")
+		return
+	}
+	f, err := share.Open(rel)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, "file_not_found")
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		writeError(w, r, http.StatusNotFound, "file_not_found")
 		return
 	}
 
 	// 设置响应头
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", safeFilename))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": info.Name()}))
 	w.Header().Set("Content-Type", "application/octet-stream")
 
 	// 发送文件
-	http.ServeFile(w, r, filePath)
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
 }
 
 // // 文件删除处理函数
@@ -292,20 +528,32 @@ func (t *AppServer) sanitizeFilename(filename string) string {
 	return filename
 }
 
-// 历史记录文件路径
-func (t *AppServer) historyFilePath() string {
-	return filepath.Join(t.UploadDir, "history.json")
+// 清理请求中的相对路径，去掉 .. 等跳出共享文件夹的部分
+func cleanRelPath(rel string) string {
+	return strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(rel)), "/")
 }
 
+// 历史记录文件，位于共享文件夹根目录
+const historyFileName = "history.json"
+
+// 修改历史记录时加锁，桌面端重命名文件时也会修改
+var historyMu sync.Mutex
+
 // 读取上传历史
 func (t *AppServer) readHistory() ([]FileInfo, error) {
-	// 检查历史文件是否存在
-	if _, err := os.Stat(t.historyFilePath()); os.IsNotExist(err) {
-		return []FileInfo{}, nil
+	share, err := t.storage()
+	if err != nil {
+		return nil, err
 	}
+	return readHistoryFile(share)
+}
 
-	// 读取历史文件
-	data, err := os.ReadFile(t.historyFilePath())
+func readHistoryFile(share Storage) ([]FileInfo, error) {
+	// 读取历史文件，不存在时返回空记录
+	data, err := readStorageFile(share, historyFileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []FileInfo{}, nil
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -319,8 +567,28 @@ func (t *AppServer) readHistory() ([]FileInfo, error) {
 	return history, nil
 }
 
+// 上传完成后记录到历史并通知桌面端
+func (t *AppServer) fileReceived(file FileInfo, device string) {
+	file.Device = device
+	if err := t.updateHistory(file); err != nil {
+		log.Printf("更新历史记录失败: %v", err)
+	}
+	if t.OnFileReceived != nil {
+		t.OnFileReceived(file, device)
+	}
+}
+
+// 发出请求的设备的显示名称
+func (t *AppServer) deviceName(r *http.Request) string {
+	dev, _ := t.Devices.Get(deviceID(r))
+	return dev.DisplayName()
+}
+
 // 更新上传历史
 func (t *AppServer) updateHistory(fileInfo FileInfo) error {
+	historyMu.Lock()
+	defer historyMu.Unlock()
+
 	// 读取现有历史
 	history, err := t.readHistory()
 	if err != nil {
@@ -336,12 +604,19 @@ func (t *AppServer) updateHistory(fileInfo FileInfo) error {
 	}
 
 	// 保存历史记录
-	data, err := json.Marshal(history)
+	share, err := t.storage()
 	if err != nil {
 		return err
 	}
+	return writeHistoryFile(share, history)
+}
 
-	return os.WriteFile(t.historyFilePath(), data, 0644)
+func writeHistoryFile(share Storage, history []FileInfo) error {
+	data, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	return writeStorageFile(share, historyFileName, data)
 }
 
 func (t *AppServer) getFileList(w http.ResponseWriter, r *http.Request) {
@@ -364,16 +639,37 @@ func (t *AppServer) getFileList(w http.ResponseWriter, r *http.Request) {
 	// 	return
 	// }
 
-	path := r.URL.Query().Get("path")
-	var items []FileItem
+	if !t.checkRead(w, r) {
+		return
+	}
 
-	entries, err := os.ReadDir(t.UploadDir + path)
+	dir := cleanRelPath(r.URL.Query().Get("path"))
+	items := []FileItem{}
+
+	// 隐藏的目录当作不存在
+	if t.isHidden(dir, true) {
+		writeError(w, r, http.StatusNotFound, "dir_not_found")
+		return
+	}
+
+	share, err := t.storage()
+	if err != nil {
+		log.Printf("打开共享文件夹失败: %v", err)
+		writeError(w, r, http.StatusNotFound, "dir_not_found")
+		return
+	}
+	entries, err := share.List(dir)
 	if err != nil {
 		fmt.Printf("读取目录失败: %v\n", err)
+		writeError(w, r, http.StatusNotFound, "dir_not_found")
 		return
 	}
 
 	for _, entry := range entries {
+		if t.isHidden(path.Join(dir, entry.Name()), entry.IsDir()) {
+			continue
+		}
+
 		itemType := "file"
 		if entry.IsDir() {
 			itemType = "folder"
@@ -385,10 +681,8 @@ func (t *AppServer) getFileList(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	json.NewEncoder(w).Encode(map[string]any{
-		"message": "文件上传成功",
-		"list":    items,
-		"code":    200,
+	writeResult(w, r, http.StatusOK, "ok", map[string]any{
+		"list": items,
 	})
 }
 
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 设置窗口，保存前检查所有设置，有错误时不关闭
+func showSettingsDialog(window fyne.Window, state *AppState, trayOK bool) {
+	config := state.config()
+
+	// 常规
+	portEntry := widget.NewEntry()
+	portEntry.SetText(strconv.Itoa(config.Port))
+	bindEntry := widget.NewEntry()
+	bindEntry.SetText(config.BindAddress)
+	bindEntry.SetPlaceHolder(tr("ui.settings_bind_all"))
+	modeValues := []ShareMode{ModeReadWrite, ModeReadOnly, ModeUploadOnly}
+	modeSelect := widget.NewSelect([]string{tr("ui.mode_readwrite"), tr("ui.mode_readonly"), tr("ui.mode_uploadonly")}, nil)
+	for i, v := range modeValues {
+		if v == config.Mode {
+			modeSelect.SetSelectedIndex(i)
+		}
+	}
+	approvalCheck := widget.NewCheck(tr("ui.require_approval"), nil)
+	approvalCheck.SetChecked(config.RequireApproval)
+	symlinkCheck := widget.NewCheck(tr("ui.follow_symlinks"), nil)
+	symlinkCheck.SetChecked(config.FollowSymlinks)
+	autoStartCheck := widget.NewCheck(tr("ui.settings_auto_start"), nil)
+	autoStartCheck.SetChecked(config.AutoStart)
+	general := widget.NewForm(
+		widget.NewFormItem(tr("ui.settings_port"), portEntry),
+		widget.NewFormItem(tr("ui.settings_bind"), bindEntry),
+		widget.NewFormItem(tr("ui.share_mode"), modeSelect),
+		widget.NewFormItem("", approvalCheck),
+		widget.NewFormItem("", symlinkCheck),
+		widget.NewFormItem("", autoStartCheck),
+	)
+
+	// 访问密码
+	userEntry := widget.NewEntry()
+	userEntry.SetText(config.Auth.Username)
+	userEntry.SetPlaceHolder(tr("ui.settings_auth_any_user"))
+	// 只保存了密码的哈希，留空表示不修改，清除后不再需要密码
+	passEntry := widget.NewPasswordEntry()
+	passEntry.SetPlaceHolder(tr("ui.settings_auth_none"))
+	keepPassword := config.Auth.enabled()
+	var clearPassBtn *widget.Button
+	clearPassBtn = widget.NewButton(tr("ui.settings_auth_clear"), func() {
+		keepPassword = false
+		passEntry.SetText("")
+		passEntry.SetPlaceHolder(tr("ui.settings_auth_none"))
+		clearPassBtn.Disable()
+	})
+	if keepPassword {
+		passEntry.SetPlaceHolder(tr("ui.settings_auth_keep"))
+	} else {
+		clearPassBtn.Disable()
+	}
+	auth := widget.NewForm(
+		widget.NewFormItem(tr("ui.settings_auth_user"), userEntry),
+		widget.NewFormItem(tr("ui.settings_auth_password"), container.NewBorder(nil, nil, nil, clearPassBtn, passEntry)),
+	)
+	auth.Append("", widget.NewLabel(tr("ui.settings_auth_hint")))
+
+	// 上传限制，以 MB 为单位，没有修改时保留原来的值
+	mbEntry := func(v int64) (*widget.Entry, func() int64) {
+		text := strconv.FormatInt(v>>20, 10)
+		entry := widget.NewEntry()
+		entry.SetText(text)
+		return entry, func() int64 {
+			if entry.Text == text {
+				return v
+			}
+			mb, err := strconv.ParseInt(strings.TrimSpace(entry.Text), 10, 64)
+			if err != nil {
+				return -1
+			}
+			return mb << 20
+		}
+	}
+	maxFileEntry, maxFile := mbEntry(config.Limits.MaxFileSize)
+	clientQuotaEntry, clientQuota := mbEntry(config.Limits.ClientQuota)
+	shareQuotaEntry, shareQuota := mbEntry(config.Limits.ShareQuota)
+	minFreeEntry, minFree := mbEntry(config.Limits.MinFreeSpace)
+	preallocCheck := widget.NewCheck(tr("ui.settings_preallocate"), nil)
+	preallocCheck.SetChecked(config.Limits.Preallocate)
+	limits := widget.NewForm(
+		widget.NewFormItem(tr("ui.settings_max_file"), maxFileEntry),
+		widget.NewFormItem(tr("ui.settings_client_quota"), clientQuotaEntry),
+		widget.NewFormItem(tr("ui.settings_share_quota"), shareQuotaEntry),
+		widget.NewFormItem(tr("ui.settings_min_free"), minFreeEntry),
+		widget.NewFormItem("", preallocCheck),
+	)
+	limits.Append("", widget.NewLabel(tr("ui.settings_limits_hint")))
+
+	// 界面
+	langValues := []string{"", string(LangZH), string(LangEN)}
+	langSelect := widget.NewSelect([]string{tr("ui.lang_auto"), "中文", "English"}, nil)
+	for i, v := range langValues {
+		if v == config.UI.Language {
+			langSelect.SetSelectedIndex(i)
+		}
+	}
+	trayCheck := widget.NewCheck(tr("ui.minimize_to_tray"), nil)
+	trayCheck.SetChecked(config.UI.MinimizeToTray)
+	if !trayOK {
+		trayCheck.Hide()
+	}
+	ui := widget.NewForm(
+		widget.NewFormItem(tr("ui.language"), langSelect),
+		widget.NewFormItem("", trayCheck),
+	)
+
+	var d *dialog.CustomDialog
+	save := func() {
+		// 从当前状态开始，打开设置期间设备、链接等的变化不会被覆盖
+		c := state.config()
+		port, err := strconv.Atoi(strings.TrimSpace(portEntry.Text))
+		if err != nil {
+			port = -1
+		}
+		c.Port = port
+		c.BindAddress = strings.TrimSpace(bindEntry.Text)
+		c.Mode = modeValues[max(modeSelect.SelectedIndex(), 0)]
+		c.RequireApproval = approvalCheck.Checked
+		c.FollowSymlinks = symlinkCheck.Checked
+		c.AutoStart = autoStartCheck.Checked
+		switch username := strings.TrimSpace(userEntry.Text); {
+		case passEntry.Text != "":
+			c.Auth = NewAuthConfig(username, passEntry.Text)
+		case keepPassword:
+			c.Auth = config.Auth
+			c.Auth.Username = username
+		default:
+			c.Auth = AuthConfig{Username: username}
+		}
+		c.Limits = UploadLimits{
+			MaxFileSize:  maxFile(),
+			ClientQuota:  clientQuota(),
+			ShareQuota:   shareQuota(),
+			MinFreeSpace: minFree(),
+			Preallocate:  preallocCheck.Checked,
+		}
+		c.UI.Language = langValues[max(langSelect.SelectedIndex(), 0)]
+		c.UI.MinimizeToTray = trayCheck.Checked
+		if err := c.Validate(); err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+
+		state.applyConfig(c)
+		if err := c.Save(configFilePath()); err != nil {
+			dialog.ShowError(errors.New(tr("ui.settings_save_failed", err)), window)
+			return
+		}
+		d.Hide()
+
+		// 共享模式和上传确认立即生效，其他共享设置在重新开始共享后生效
+		running, _ := state.ServerRunning.Get()
+		if state.Server != nil {
+			state.Server.SetMode(c.Mode)
+			state.Server.SetRequireApproval(c.RequireApproval)
+		}
+		switch {
+		case running && (c.Port != config.Port || c.BindAddress != config.BindAddress ||
+			c.Auth != config.Auth || c.Limits != config.Limits || c.FollowSymlinks != config.FollowSymlinks):
+			showToast(tr("ui.settings_restart_sharing"), window)
+		case c.UI.Language != config.UI.Language:
+			showToast(tr("ui.settings_restart_app"), window)
+		}
+	}
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem(tr("ui.settings_general"), general),
+		container.NewTabItem(tr("ui.settings_auth"), auth),
+		container.NewTabItem(tr("ui.settings_limits"), limits),
+		container.NewTabItem(tr("ui.settings_ui"), ui),
+	)
+	saveBtn := widget.NewButton(tr("ui.settings_save"), save)
+	saveBtn.Importance = widget.HighImportance
+	buttons := container.NewHBox(saveBtn, widget.NewButton(tr("ui.cancel"), func() { d.Hide() }))
+	d = dialog.NewCustomWithoutButtons(tr("ui.settings"), container.NewBorder(nil, container.NewCenter(buttons), nil, nil, tabs), window)
+	d.Resize(fyne.NewSize(520, 420))
+	d.Show()
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	// 选择等待时，最多等待传输完成的时间
+	stopWaitTimeout = time.Minute
+	// 没有传输时关闭空闲连接的时间
+	stopIdleTimeout = 5 * time.Second
+)
+
+// 停止共享。有正在进行的传输时先列出并让用户选择等待完成或立即停止，
+// 服务器在后台关闭，不会阻塞界面；结束后在界面线程调用 done，用户取消时 stopped 为 false
+func stopSharing(window fyne.Window, server *AppServer, done func(stopped bool)) {
+	if len(server.ActiveTransfers()) == 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), stopIdleTimeout)
+		go func() {
+			defer cancel()
+			server.Stop(ctx)
+			fyne.Do(func() { done(true) })
+		}()
+		return
+	}
+
+	transfers, refresh := newTransferList(server)
+	summary := widget.NewLabel("")
+	updateSummary := func() {
+		summary.SetText(tr("ui.stop_active", len(server.ActiveTransfers())))
+	}
+	updateSummary()
+
+	// 打开期间每秒刷新传输进度
+	ticker := time.NewTicker(time.Second)
+	stopTicker := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				fyne.Do(func() {
+					refresh()
+					updateSummary()
+				})
+			case <-stopTicker:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	var d *dialog.CustomDialog
+	finish := func(stopped bool) {
+		close(stopTicker)
+		d.Hide()
+		done(stopped)
+	}
+
+	// 后台关闭服务器，ctx 结束时强制断开
+	var force context.CancelFunc
+	stopping := false
+	waitBtn := widget.NewButton(tr("ui.stop_wait", int(stopWaitTimeout.Seconds())), nil)
+	forceBtn := widget.NewButton(tr("ui.stop_force"), nil)
+	cancelBtn := widget.NewButton(tr("ui.cancel"), func() { finish(false) })
+	forceBtn.Importance = widget.DangerImportance
+
+	start := func(timeout time.Duration) {
+		stopping = true
+		waitBtn.Disable()
+		cancelBtn.Disable()
+		var ctx context.Context
+		ctx, force = context.WithTimeout(context.Background(), timeout)
+		deadline := time.Now().Add(timeout)
+		updateSummary = func() {
+			left := max(int(time.Until(deadline).Seconds()), 0)
+			summary.SetText(tr("ui.stop_waiting", len(server.ActiveTransfers()), left))
+		}
+		updateSummary()
+		go func() {
+			defer force()
+			server.Stop(ctx)
+			fyne.Do(func() { finish(true) })
+		}()
+	}
+	waitBtn.OnTapped = func() { start(stopWaitTimeout) }
+	forceBtn.OnTapped = func() {
+		if stopping {
+			force()
+			return
+		}
+		start(0)
+	}
+
+	content := container.NewBorder(summary, container.NewHBox(waitBtn, forceBtn, cancelBtn), nil, nil, transfers)
+	d = dialog.NewCustomWithoutButtons(tr("ui.stop_title"), content, window)
+	d.Resize(fyne.NewSize(520, 360))
+	d.Show()
+}
+
+// 正在进行的传输列表，refresh 重新读取进度
+func newTransferList(server *AppServer) (fyne.CanvasObject, func()) {
+	items := server.ActiveTransfers()
+	list := widget.NewList(
+		func() int { return len(items) },
+		func() fyne.CanvasObject {
+			return container.NewVBox(
+				widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				widget.NewLabel(""),
+			)
+		},
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			item := items[i]
+			rows := o.(*fyne.Container).Objects
+			rows[0].(*widget.Label).SetText(fmt.Sprintf("%s · %s", item.Device, tr("ui.audit_"+item.Action)))
+			rows[1].(*widget.Label).SetText(fmt.Sprintf("%s · %s", item.Path, transferProgress(item)))
+		},
+	)
+	return list, func() {
+		items = server.ActiveTransfers()
+		list.Refresh()
+	}
+}
+
+// 已传输的大小，知道总大小时一并显示
+func transferProgress(t Transfer) string {
+	if t.Size > 0 {
+		return formatFileSize(t.Bytes) + " / " + formatFileSize(t.Size)
+	}
+	return formatFileSize(t.Bytes)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+)
+
+// 共享文件夹的存储，路径均为相对共享文件夹、以 / 分隔的路径，"" 表示根目录
+type Storage interface {
+	List(rel string) ([]fs.FileInfo, error)
+	Stat(rel string) (fs.FileInfo, error)
+	Open(rel string) (StorageFile, error)
+	// 创建或覆盖文件，写入的内容在 Close 成功后才算保存
+	Create(rel string) (io.WriteCloser, error)
+	Rename(from, to string) error
+	// 删除文件或空目录
+	Remove(rel string) error
+	MkdirAll(rel string) error
+	// 递归遍历目录，fn 可返回 fs.SkipDir 或 fs.SkipAll
+	Walk(rel string, fn WalkFunc) error
+	Close() error
+}
+
+type WalkFunc func(rel string, info fs.FileInfo) error
+
+// 可随机读取的文件，用于下载时支持断点续传
+type StorageFile interface {
+	io.ReadSeekCloser
+	Stat() (fs.FileInfo, error)
+}
+
+var errDirNotEmpty = errors.New("目录不为空")
+
+// 存储配置
+type StorageConfig struct {
+	Type string   `json:"type"` // local 或 s3，默认 local
+	S3   S3Config `json:"s3"`
+}
+
+// 根据配置创建存储，本地存储使用共享文件夹
+func (c StorageConfig) Open(uploadDir string, followSymlinks bool) (Storage, error) {
+	switch c.Type {
+	case "", "local":
+		s, err := OpenShareFS(uploadDir, followSymlinks)
+		if err != nil {
+			return nil, err
+		}
+		return s, nil
+	case "s3":
+		return NewS3Storage(c.S3)
+	default:
+		return nil, errors.New("未知的存储类型: " + c.Type)
+	}
+}
+
+// 获取共享文件夹的存储，未指定时在首次使用时打开本地文件夹
+func (t *AppServer) storage() (Storage, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.Storage == nil {
+		s, err := OpenShareFS(t.UploadDir, t.FollowSymlinks)
+		if err != nil {
+			return nil, err
+		}
+		t.Storage = s
+	}
+	return t.Storage, nil
+}
+
+func readStorageFile(s Storage, rel string) ([]byte, error) {
+	f, err := s.Open(rel)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+func writeStorageFile(s Storage, rel string, data []byte) error {
+	f, err := s.Create(rel)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// 基于 List 的递归遍历，供没有更高效遍历方式的存储使用
+func walkList(s Storage, dir string, fn WalkFunc) error {
+	err := walkListDir(s, cleanRelPath(dir), fn)
+	if err == fs.SkipAll {
+		return nil
+	}
+	return err
+}
+
+func walkListDir(s Storage, dir string, fn WalkFunc) error {
+	infos, err := s.List(dir)
+	if err != nil {
+		return nil // 忽略无法访问的目录
+	}
+	for _, info := range infos {
+		rel := path.Join(dir, info.Name())
+		if err := fn(rel, info); err != nil {
+			if err == fs.SkipDir && info.IsDir() {
+				continue
+			}
+			return err
+		}
+		if info.IsDir() {
+			if err := walkListDir(s, rel, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// 非本地存储使用的文件信息
+type entryInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (e *entryInfo) Name() string       { return e.name }
+func (e *entryInfo) Size() int64        { return e.size }
+func (e *entryInfo) ModTime() time.Time { return e.modTime }
+func (e *entryInfo) IsDir() bool        { return e.dir }
+func (e *entryInfo) Sys() any           { return nil }
+
+func (e *entryInfo) Mode() fs.FileMode {
+	if e.dir {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
+
+// 上级目录，根目录下的路径返回 ""
+func parentDir(rel string) string {
+	if dir := path.Dir(rel); dir != "." {
+		return dir
+	}
+	return ""
+}
+
+// 判断 rel 是否位于目录 dir 之内
+func withinDir(rel, dir string) bool {
+	return dir == "" || rel == dir || strings.HasPrefix(rel, dir+"/")
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 内存中的存储，用于测试处理函数而不读写磁盘
+type MemStorage struct {
+	mu      sync.Mutex
+	entries map[string]*memEntry // 除根目录外的所有文件和目录
+}
+
+type memEntry struct {
+	data    []byte
+	dir     bool
+	modTime time.Time
+}
+
+func NewMemStorage() *MemStorage {
+	return &MemStorage{entries: make(map[string]*memEntry)}
+}
+
+func (s *MemStorage) info(rel string) (fs.FileInfo, bool) {
+	if rel == "" {
+		return &entryInfo{name: ".", dir: true}, true
+	}
+	e, ok := s.entries[rel]
+	if !ok {
+		return nil, false
+	}
+	return &entryInfo{name: path.Base(rel), size: int64(len(e.data)), modTime: e.modTime, dir: e.dir}, true
+}
+
+func (s *MemStorage) List(rel string) ([]fs.FileInfo, error) {
+	rel = cleanRelPath(rel)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if info, ok := s.info(rel); !ok || !info.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: rel, Err: fs.ErrNotExist}
+	}
+
+	var infos []fs.FileInfo
+	for name := range s.entries {
+		if parentDir(name) == rel {
+			info, _ := s.info(name)
+			infos = append(infos, info)
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+	return infos, nil
+}
+
+func (s *MemStorage) Stat(rel string) (fs.FileInfo, error) {
+	rel = cleanRelPath(rel)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info, ok := s.info(rel)
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: rel, Err: fs.ErrNotExist}
+	}
+	return info, nil
+}
+
+func (s *MemStorage) Open(rel string) (StorageFile, error) {
+	rel = cleanRelPath(rel)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info, ok := s.info(rel)
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: rel, Err: fs.ErrNotExist}
+	}
+	var data []byte
+	if e := s.entries[rel]; e != nil {
+		data = e.data
+	}
+	return &memFile{Reader: bytes.NewReader(data), info: info}, nil
+}
+
+func (s *MemStorage) Create(rel string) (io.WriteCloser, error) {
+	rel = cleanRelPath(rel)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[rel]; rel == "" || ok && e.dir {
+		return nil, &fs.PathError{Op: "create", Path: rel, Err: fs.ErrInvalid}
+	}
+	if parent, ok := s.info(parentDir(rel)); !ok || !parent.IsDir() {
+		return nil, &fs.PathError{Op: "create", Path: rel, Err: fs.ErrNotExist}
+	}
+	s.entries[rel] = &memEntry{modTime: time.Now()}
+	return &memWriter{s: s, rel: rel}, nil
+}
+
+func (s *MemStorage) Rename(from, to string) error {
+	from, to = cleanRelPath(from), cleanRelPath(to)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[from]; !ok {
+		return &fs.PathError{Op: "rename", Path: from, Err: fs.ErrNotExist}
+	}
+	if _, ok := s.entries[to]; ok {
+		return &fs.PathError{Op: "rename", Path: to, Err: fs.ErrExist}
+	}
+	if parent, ok := s.info(parentDir(to)); !ok || !parent.IsDir() || withinDir(to, from) {
+		return &fs.PathError{Op: "rename", Path: to, Err: fs.ErrInvalid}
+	}
+
+	moved := make(map[string]*memEntry)
+	for name, e := range s.entries {
+		if withinDir(name, from) {
+			moved[to+name[len(from):]] = e
+			delete(s.entries, name)
+		}
+	}
+	for name, e := range moved {
+		s.entries[name] = e
+	}
+	return nil
+}
+
+func (s *MemStorage) Remove(rel string) error {
+	rel = cleanRelPath(rel)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[rel]; !ok {
+		return &fs.PathError{Op: "remove", Path: rel, Err: fs.ErrNotExist}
+	}
+	for name := range s.entries {
+		if name != rel && withinDir(name, rel) {
+			return &fs.PathError{Op: "remove", Path: rel, Err: errDirNotEmpty}
+		}
+	}
+	delete(s.entries, rel)
+	return nil
+}
+
+func (s *MemStorage) MkdirAll(rel string) error {
+	rel = cleanRelPath(rel)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rel == "" {
+		return nil
+	}
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		dir := strings.Join(parts[:i+1], "/")
+		if e, ok := s.entries[dir]; ok {
+			if !e.dir {
+				return &fs.PathError{Op: "mkdir", Path: dir, Err: fs.ErrExist}
+			}
+			continue
+		}
+		s.entries[dir] = &memEntry{dir: true, modTime: time.Now()}
+	}
+	return nil
+}
+
+func (s *MemStorage) Walk(rel string, fn WalkFunc) error {
+	return walkList(s, rel, fn)
+}
+
+func (s *MemStorage) Close() error {
+	return nil
+}
+
+type memFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memFile) Close() error               { return nil }
+
+// 写入内容在关闭时保存
+type memWriter struct {
+	s   *MemStorage
+	rel string
+	buf bytes.Buffer
+}
+
+func (w *memWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *memWriter) Close() error {
+	w.s.mu.Lock()
+	defer w.s.mu.Unlock()
+	if e, ok := w.s.entries[w.rel]; ok && !e.dir {
+		e.data = bytes.Clone(w.buf.Bytes())
+		e.modTime = time.Now()
+	}
+	return nil
+}
@@ -0,0 +1,609 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3 兼容存储的配置，使用路径形式的地址，兼容 MinIO 等自建服务
+type S3Config struct {
+	Endpoint  string `json:"endpoint"` // 如 http://127.0.0.1:9000
+	Region    string `json:"region"`
+	Bucket    string `json:"bucket"`
+	Prefix    string `json:"prefix"` // 共享文件夹在存储桶中的前缀
+	AccessKey string `json:"accessKey"`
+	SecretKey string `json:"secretKey"`
+}
+
+// S3 兼容存储，目录以 / 分隔的前缀表示，空目录保存为以 / 结尾的空对象
+type S3Storage struct {
+	cfg      S3Config
+	endpoint *url.URL
+	prefix   string
+	client   *http.Client
+}
+
+func NewS3Storage(cfg S3Config) (*S3Storage, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, errors.New("S3 存储需要设置地址和存储桶")
+	}
+	endpoint, err := url.Parse(strings.TrimRight(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("S3 地址无效: %s", cfg.Endpoint)
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	prefix := strings.Trim(cfg.Prefix, "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	return &S3Storage{
+		cfg:      cfg,
+		endpoint: endpoint,
+		prefix:   prefix,
+		client:   &http.Client{},
+	}, nil
+}
+
+// 相对路径对应的对象名
+func (s *S3Storage) key(rel string) string {
+	return s.prefix + cleanRelPath(rel)
+}
+
+// 目录对应的前缀，根目录为共享文件夹的前缀
+func (s *S3Storage) dirKey(rel string) string {
+	if rel = cleanRelPath(rel); rel == "" {
+		return s.prefix
+	}
+	return s.prefix + rel + "/"
+}
+
+// S3 返回的错误
+type s3Error struct {
+	Status  int
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (e *s3Error) Error() string {
+	return fmt.Sprintf("S3 错误 %d %s: %s", e.Status, e.Code, e.Message)
+}
+
+// 对象不存在时返回 fs.ErrNotExist，便于与本地存储统一处理
+func (e *s3Error) Unwrap() error {
+	if e.Status == http.StatusNotFound {
+		return fs.ErrNotExist
+	}
+	return nil
+}
+
+// 发送签名后的请求，key 为空时请求存储桶本身
+func (s *S3Storage) do(method, key string, query url.Values, header http.Header, body io.Reader, size int64) (*http.Response, error) {
+	u := *s.endpoint
+	u.Path = "/" + s.cfg.Bucket
+	u.RawPath = "/" + s3Escape(s.cfg.Bucket, false)
+	if key != "" {
+		u.Path += "/" + key
+		u.RawPath += "/" + s3Escape(key, true)
+	}
+	u.RawQuery = s3Query(query)
+
+	// 空内容不使用分块传输，S3 要求上传时指定长度
+	if size == 0 {
+		body = nil
+	}
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	signS3Request(req, s.cfg.AccessKey, s.cfg.SecretKey, s.cfg.Region, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		e := &s3Error{Status: resp.StatusCode}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		xml.Unmarshal(data, e)
+		return nil, e
+	}
+	return resp, nil
+}
+
+// 发送请求并丢弃响应内容
+func (s *S3Storage) exec(method, key string, query url.Values, header http.Header) error {
+	resp, err := s.do(method, key, query, header, nil, 0)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+type s3ListResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// 列出前缀下的对象，delimiter 为 / 时只列出一层，fn 返回 false 时停止
+func (s *S3Storage) list(prefix, delimiter string, maxKeys int, fn func(*s3ListResult) bool) error {
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	if delimiter != "" {
+		query.Set("delimiter", delimiter)
+	}
+	if maxKeys > 0 {
+		query.Set("max-keys", strconv.Itoa(maxKeys))
+	}
+	for {
+		resp, err := s.do(http.MethodGet, "", query, nil, nil, 0)
+		if err != nil {
+			return err
+		}
+		var result s3ListResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		if !fn(&result) || !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+		query.Set("continuation-token", result.NextContinuationToken)
+	}
+}
+
+// 判断前缀下是否有对象，即目录是否存在
+func (s *S3Storage) dirExists(rel string) (bool, error) {
+	if cleanRelPath(rel) == "" {
+		return true, nil
+	}
+	found := false
+	err := s.list(s.dirKey(rel), "", 1, func(r *s3ListResult) bool {
+		found = len(r.Contents) > 0
+		return false
+	})
+	return found, err
+}
+
+func (s *S3Storage) List(rel string) ([]fs.FileInfo, error) {
+	prefix := s.dirKey(rel)
+	var infos []fs.FileInfo
+	dirs := make(map[string]bool)
+	addDir := func(name string) {
+		if !dirs[name] {
+			dirs[name] = true
+			infos = append(infos, &entryInfo{name: name, dir: true})
+		}
+	}
+	found := prefix == s.prefix
+	err := s.list(prefix, "/", 0, func(r *s3ListResult) bool {
+		for _, c := range r.CommonPrefixes {
+			found = true
+			addDir(path.Base(strings.TrimSuffix(c.Prefix, "/")))
+		}
+		for _, c := range r.Contents {
+			found = true
+			switch {
+			case c.Key == prefix:
+				// 目录本身
+			case strings.HasSuffix(c.Key, "/"):
+				// 部分实现会把空目录作为对象返回
+				addDir(path.Base(strings.TrimSuffix(c.Key, "/")))
+			default:
+				infos = append(infos, &entryInfo{name: path.Base(c.Key), size: c.Size, modTime: c.LastModified})
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: rel, Err: fs.ErrNotExist}
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+	return infos, nil
+}
+
+func (s *S3Storage) Stat(rel string) (fs.FileInfo, error) {
+	rel = cleanRelPath(rel)
+	if rel == "" {
+		return &entryInfo{name: ".", dir: true}, nil
+	}
+
+	resp, err := s.do(http.MethodHead, s.key(rel), nil, nil, nil, 0)
+	if err == nil {
+		resp.Body.Close()
+		modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+		return &entryInfo{name: path.Base(rel), size: resp.ContentLength, modTime: modTime}, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	if ok, err := s.dirExists(rel); err != nil {
+		return nil, err
+	} else if ok {
+		return &entryInfo{name: path.Base(rel), dir: true}, nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: rel, Err: fs.ErrNotExist}
+}
+
+func (s *S3Storage) Open(rel string) (StorageFile, error) {
+	info, err := s.Stat(rel)
+	if err != nil {
+		return nil, err
+	}
+	return &s3File{s: s, key: s.key(rel), info: info}, nil
+}
+
+// 先写入临时文件，关闭时一次上传，S3 需要预先知道对象大小
+func (s *S3Storage) Create(rel string) (io.WriteCloser, error) {
+	rel = cleanRelPath(rel)
+	if rel == "" {
+		return nil, &fs.PathError{Op: "create", Path: rel, Err: fs.ErrInvalid}
+	}
+	tmp, err := os.CreateTemp("", "kuaichuan-s3-*")
+	if err != nil {
+		return nil, err
+	}
+	return &s3Writer{s: s, key: s.key(rel), tmp: tmp}, nil
+}
+
+// 复制后删除原对象，目录会逐个移动其中的对象
+func (s *S3Storage) Rename(from, to string) error {
+	from, to = cleanRelPath(from), cleanRelPath(to)
+	if from == "" || to == "" || withinDir(to, from) {
+		return &fs.PathError{Op: "rename", Path: from, Err: fs.ErrInvalid}
+	}
+	info, err := s.Stat(from)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return s.move(s.key(from), s.key(to))
+	}
+
+	var keys []string
+	err = s.list(s.dirKey(from), "", 0, func(r *s3ListResult) bool {
+		for _, c := range r.Contents {
+			keys = append(keys, c.Key)
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	oldPrefix, newPrefix := s.dirKey(from), s.dirKey(to)
+	for _, key := range keys {
+		if err := s.move(key, newPrefix+strings.TrimPrefix(key, oldPrefix)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *S3Storage) move(from, to string) error {
+	header := http.Header{"X-Amz-Copy-Source": {"/" + s3Escape(s.cfg.Bucket, false) + "/" + s3Escape(from, true)}}
+	if err := s.exec(http.MethodPut, to, nil, header); err != nil {
+		return err
+	}
+	return s.exec(http.MethodDelete, from, nil, nil)
+}
+
+func (s *S3Storage) Remove(rel string) error {
+	info, err := s.Stat(rel)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return s.exec(http.MethodDelete, s.key(rel), nil, nil)
+	}
+
+	// 目录中只剩下目录本身时才能删除
+	prefix := s.dirKey(rel)
+	empty := true
+	err = s.list(prefix, "", 2, func(r *s3ListResult) bool {
+		for _, c := range r.Contents {
+			if c.Key != prefix {
+				empty = false
+			}
+		}
+		return false
+	})
+	if err != nil {
+		return err
+	}
+	if !empty {
+		return &fs.PathError{Op: "remove", Path: rel, Err: errDirNotEmpty}
+	}
+	err = s.exec(http.MethodDelete, prefix, nil, nil)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// 创建以 / 结尾的空对象表示目录，上级目录由前缀隐含
+func (s *S3Storage) MkdirAll(rel string) error {
+	if cleanRelPath(rel) == "" {
+		return nil
+	}
+	resp, err := s.do(http.MethodPut, s.dirKey(rel), nil, nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// 一次列出前缀下的所有对象，按对象名推出其中的目录
+func (s *S3Storage) Walk(rel string, fn WalkFunc) error {
+	base := cleanRelPath(rel)
+	seen := make(map[string]bool)
+	var skipped []string
+	isSkipped := func(p string) bool {
+		for _, dir := range skipped {
+			if withinDir(p, dir) {
+				return true
+			}
+		}
+		return false
+	}
+	// 返回 false 表示停止遍历
+	visit := func(p string, info fs.FileInfo) (bool, error) {
+		if isSkipped(p) {
+			return true, nil
+		}
+		err := fn(p, info)
+		switch {
+		case err == fs.SkipDir && info.IsDir():
+			skipped = append(skipped, p)
+		case err == fs.SkipAll:
+			return false, nil
+		case err != nil:
+			return false, err
+		}
+		return true, nil
+	}
+
+	var walkErr error
+	err := s.list(s.dirKey(base), "", 0, func(r *s3ListResult) bool {
+		for _, c := range r.Contents {
+			p := strings.TrimPrefix(c.Key, s.prefix)
+			isDir := strings.HasSuffix(p, "/")
+			p = strings.TrimSuffix(p, "/")
+
+			// 先访问尚未出现过的上级目录
+			parts := strings.Split(p, "/")
+			for i := range parts[:len(parts)-1] {
+				dir := strings.Join(parts[:i+1], "/")
+				if !withinDir(dir, base) || dir == base || seen[dir] {
+					continue
+				}
+				seen[dir] = true
+				if ok, err := visit(dir, &entryInfo{name: parts[i], dir: true}); !ok {
+					walkErr = err
+					return false
+				}
+			}
+			if p == base || seen[p] {
+				continue
+			}
+			seen[p] = true
+			info := &entryInfo{name: path.Base(p), size: c.Size, modTime: c.LastModified, dir: isDir}
+			if ok, err := visit(p, info); !ok {
+				walkErr = err
+				return false
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	return walkErr
+}
+
+func (s *S3Storage) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+// 按需使用 Range 请求读取的对象
+type s3File struct {
+	s    *S3Storage
+	key  string
+	info fs.FileInfo
+	off  int64
+	body io.ReadCloser
+}
+
+func (f *s3File) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *s3File) Read(p []byte) (int, error) {
+	if f.off >= f.info.Size() {
+		return 0, io.EOF
+	}
+	if f.body == nil {
+		header := http.Header{"Range": {fmt.Sprintf("bytes=%d-", f.off)}}
+		resp, err := f.s.do(http.MethodGet, f.key, nil, header, nil, 0)
+		if err != nil {
+			return 0, err
+		}
+		f.body = resp.Body
+	}
+	n, err := f.body.Read(p)
+	f.off += int64(n)
+	return n, err
+}
+
+func (f *s3File) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.off
+	case io.SeekEnd:
+		offset += f.info.Size()
+	}
+	if offset < 0 {
+		return 0, errors.New("无效的读取位置")
+	}
+	if offset != f.off && f.body != nil {
+		f.body.Close()
+		f.body = nil
+	}
+	f.off = offset
+	return offset, nil
+}
+
+func (f *s3File) Close() error {
+	if f.body != nil {
+		return f.body.Close()
+	}
+	return nil
+}
+
+type s3Writer struct {
+	s      *S3Storage
+	key    string
+	tmp    *os.File
+	closed bool
+}
+
+func (w *s3Writer) Write(p []byte) (int, error) {
+	return w.tmp.Write(p)
+}
+
+func (w *s3Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	defer os.Remove(w.tmp.Name())
+	defer w.tmp.Close()
+
+	size, err := w.tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := w.tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	resp, err := w.s.do(http.MethodPut, w.key, nil, nil, w.tmp, size)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// 按 AWS 签名版本 4 为请求签名，请求体不参与签名
+func signS3Request(req *http.Request, accessKey, secretKey, region string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if req.Header.Get("X-Amz-Content-Sha256") == "" {
+		req.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+	}
+
+	// 参与签名的请求头
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		req.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{date, region, "s3", "aws4_request", stringToSign} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, hex.EncodeToString(key)))
+}
+
+// 按 S3 的规则编码，只保留非保留字符
+func s3Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// 按参数名排序编码查询参数，与签名时的格式一致
+func s3Query(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, s3Escape(k, false)+"="+s3Escape(v, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
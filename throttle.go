@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 每次读写的最大块大小，保证限速平滑
+const throttleChunk = 32 << 10
+
+// 限速配置，单位为字节/秒，0 表示不限速
+type ThrottleRates struct {
+	Upload         int64                 `json:"upload"`         // 全局上传速度
+	Download       int64                 `json:"download"`       // 全局下载速度
+	ClientUpload   int64                 `json:"clientUpload"`   // 每个客户端的上传速度
+	ClientDownload int64                 `json:"clientDownload"` // 每个客户端的下载速度
+	Clients        map[string]ClientRate `json:"clients"`        // 按客户端IP单独设置的速度
+}
+
+// 单个客户端的限速配置
+type ClientRate struct {
+	Upload   int64 `json:"upload"`
+	Download int64 `json:"download"`
+}
+
+// 令牌桶，桶容量为一秒的流量
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   int64
+	tokens float64
+	last   time.Time
+}
+
+// 修改速度，立即对后续读写生效
+func (b *tokenBucket) setRate(rate int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rate = rate
+	if b.tokens > float64(rate) {
+		b.tokens = float64(rate)
+	}
+}
+
+// 消耗 n 个令牌，返回需要等待的时间
+func (b *tokenBucket) reserve(n int) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rate <= 0 {
+		return 0
+	}
+
+	now := time.Now()
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * float64(b.rate)
+		if b.tokens > float64(b.rate) {
+			b.tokens = float64(b.rate)
+		}
+	}
+	b.last = now
+
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / float64(b.rate) * float64(time.Second))
+}
+
+// 等待直到可以传输 n 个字节
+func waitBuckets(ctx context.Context, n int, buckets ...*tokenBucket) error {
+	var delay time.Duration
+	for _, b := range buckets {
+		if d := b.reserve(n); d > delay {
+			delay = d
+		}
+	}
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// 没有进行中的请求且空闲超过该时间的客户端令牌桶会被清除
+const throttleIdle = 10 * time.Minute
+
+// 客户端的上传、下载令牌桶
+type clientBuckets struct {
+	up   tokenBucket
+	down tokenBucket
+
+	active   int // 进行中的请求数，由 Throttle.mu 保护
+	lastUsed time.Time
+}
+
+// 全局及每个客户端的限速器，可在共享期间随时调整速度
+type Throttle struct {
+	mu        sync.Mutex
+	rates     ThrottleRates
+	up        tokenBucket
+	down      tokenBucket
+	clients   map[string]*clientBuckets
+	lastSweep time.Time
+}
+
+func NewThrottle(rates ThrottleRates) *Throttle {
+	t := &Throttle{clients: make(map[string]*clientBuckets)}
+	t.SetRates(rates)
+	return t
+}
+
+// 获取当前的限速配置
+func (t *Throttle) Rates() ThrottleRates {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.rates
+}
+
+// 更新限速配置
+func (t *Throttle) SetRates(rates ThrottleRates) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rates = rates
+	t.up.setRate(rates.Upload)
+	t.down.setRate(rates.Download)
+	for ip, c := range t.clients {
+		up, down := t.clientRate(ip)
+		c.up.setRate(up)
+		c.down.setRate(down)
+	}
+}
+
+// 客户端的上传、下载速度，单独设置的优先
+func (t *Throttle) clientRate(ip string) (int64, int64) {
+	if rate, ok := t.rates.Clients[ip]; ok {
+		return rate.Upload, rate.Download
+	}
+	return t.rates.ClientUpload, t.rates.ClientDownload
+}
+
+// 获取客户端的令牌桶并计入进行中的请求，请求结束后调用 release
+func (t *Throttle) acquire(ip string) *clientBuckets {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	if now.Sub(t.lastSweep) > throttleIdle {
+		t.lastSweep = now
+		t.sweep(now)
+	}
+	c, ok := t.clients[ip]
+	if !ok {
+		c = &clientBuckets{}
+		up, down := t.clientRate(ip)
+		c.up.setRate(up)
+		c.down.setRate(down)
+		t.clients[ip] = c
+	}
+	c.active++
+	c.lastUsed = now
+	return c
+}
+
+func (t *Throttle) release(c *clientBuckets) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	c.active--
+	c.lastUsed = time.Now()
+}
+
+// 清除空闲客户端的令牌桶，避免连接过的客户端越来越多
+func (t *Throttle) sweep(now time.Time) {
+	for ip, c := range t.clients {
+		if c.active == 0 && now.Sub(c.lastUsed) > throttleIdle {
+			delete(t.clients, ip)
+		}
+	}
+}
+
+// 限速的请求体
+type throttledReader struct {
+	ctx     context.Context
+	r       io.ReadCloser
+	buckets []*tokenBucket
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > throttleChunk {
+		p = p[:throttleChunk]
+	}
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if werr := waitBuckets(r.ctx, n, r.buckets...); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+func (r *throttledReader) Close() error {
+	return r.r.Close()
+}
+
+// 限速的响应
+type throttledWriter struct {
+	http.ResponseWriter
+	ctx     context.Context
+	buckets []*tokenBucket
+}
+
+func (w *throttledWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > throttleChunk {
+			chunk = chunk[:throttleChunk]
+		}
+		if err := waitBuckets(w.ctx, len(chunk), w.buckets...); err != nil {
+			return written, err
+		}
+		n, err := w.ResponseWriter.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[len(chunk):]
+	}
+	return written, nil
+}
+
+func (w *throttledWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *throttledWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// 对请求的上传、下载进行限速
+func (t *Throttle) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := t.acquire(clientIP(r))
+		defer t.release(c)
+		ctx := r.Context()
+		if r.Body != nil {
+			r.Body = &throttledReader{ctx: ctx, r: r.Body, buckets: []*tokenBucket{&t.up, &c.up}}
+		}
+		w = &throttledWriter{ResponseWriter: w, ctx: ctx, buckets: []*tokenBucket{&t.down, &c.down}}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestThrottleEvictsIdleClients(t *testing.T) {
+	th := NewThrottle(ThrottleRates{ClientDownload: 1 << 20})
+	release := make(chan struct{})
+	started := make(chan struct{})
+	h := th.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			close(started)
+			<-release
+		}
+	}))
+	get := func(ip, target string) {
+		req := httptest.NewRequest("GET", target, nil)
+		req.RemoteAddr = ip + ":1234"
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		get("10.0.0.1", "/slow")
+		close(done)
+	}()
+	<-started
+	get("10.0.0.2", "/")
+
+	// 空闲的客户端被清除，进行中的请求不受影响
+	th.mu.Lock()
+	for _, c := range th.clients {
+		c.lastUsed = c.lastUsed.Add(-2 * throttleIdle)
+	}
+	th.lastSweep = time.Time{}
+	th.mu.Unlock()
+	get("10.0.0.3", "/")
+	th.mu.Lock()
+	_, active := th.clients["10.0.0.1"]
+	_, idle := th.clients["10.0.0.2"]
+	n := len(th.clients)
+	th.mu.Unlock()
+	if !active || idle || n != 2 {
+		t.Fatalf("active kept %v, idle kept %v, %d clients", active, idle, n)
+	}
+	close(release)
+	<-done
+}
+
+func TestThrottleClientOverride(t *testing.T) {
+	th := NewThrottle(ThrottleRates{ClientDownload: 100 << 10})
+	c := th.acquire("10.0.0.1")
+	defer th.release(c)
+
+	// 单独设置的速度立即生效，删除后恢复默认
+	th.SetRates(ThrottleRates{ClientDownload: 100 << 10, Clients: map[string]ClientRate{"10.0.0.1": {Download: 1 << 20}}})
+	if c.down.rate != 1<<20 {
+		t.Fatalf("override rate = %d", c.down.rate)
+	}
+	th.SetRates(ThrottleRates{ClientDownload: 100 << 10})
+	if c.down.rate != 100<<10 {
+		t.Fatalf("default rate = %d", c.down.rate)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// 正在进行的上传或下载
+type Transfer struct {
+	ID      int64
+	Device  string
+	IP      string
+	Action  string // upload 或 download
+	Path    string
+	Size    int64 // 上传时为请求声明的大小，未知时为 -1
+	Bytes   int64 // 已传输的字节数
+	Started time.Time
+}
+
+type activeTransfer struct {
+	Transfer
+	bytes atomic.Int64
+}
+
+// 记录正在进行的上传和下载，停止共享前提示用户
+func (t *AppServer) trackTransfers(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		action, p := auditAction(r)
+		if action != AuditUpload && action != AuditDownload {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		dev, _ := t.Devices.Get(deviceID(r))
+		tr := &activeTransfer{Transfer: Transfer{
+			Device:  dev.DisplayName(),
+			IP:      clientIP(r),
+			Action:  action,
+			Path:    p,
+			Size:    -1,
+			Started: time.Now(),
+		}}
+		count := func(n int64) { tr.bytes.Add(n) }
+		if action == AuditUpload {
+			tr.Size = r.ContentLength
+			if r.Body != nil {
+				r.Body = &countingReader{r: r.Body, count: count}
+			}
+		} else {
+			w = &countingWriter{ResponseWriter: w, count: count}
+		}
+
+		t.mu.Lock()
+		if t.transfers == nil {
+			t.transfers = make(map[int64]*activeTransfer)
+		}
+		t.lastTransfer++
+		tr.ID = t.lastTransfer
+		t.transfers[tr.ID] = tr
+		t.mu.Unlock()
+		defer func() {
+			t.mu.Lock()
+			delete(t.transfers, tr.ID)
+			t.mu.Unlock()
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// 正在进行的传输，按开始时间排列
+func (t *AppServer) ActiveTransfers() []Transfer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	list := make([]Transfer, 0, len(t.transfers))
+	for _, tr := range t.transfers {
+		info := tr.Transfer
+		info.Bytes = tr.bytes.Load()
+		list = append(list, info)
+	}
+	slices.SortFunc(list, func(a, b Transfer) int {
+		return cmp.Or(a.Started.Compare(b.Started), cmp.Compare(a.ID, b.ID))
+	})
+	return list
+}
+
+// 停止共享：先通知浏览器，再停止接受新连接并等待进行中的传输完成，
+// ctx 结束时强制断开剩余的连接。会一直阻塞到服务器关闭，不能在界面线程中调用
+func (t *AppServer) Stop(ctx context.Context) {
+	seconds := 0
+	if deadline, ok := ctx.Deadline(); ok {
+		seconds = max(int(time.Until(deadline).Seconds()), 0)
+	}
+	t.events.Publish(ServerEvent{Type: "shutdown", Data: map[string]any{"seconds": seconds}})
+	t.events.Close()
+
+	t.mu.Lock()
+	srv := t.srv
+	t.mu.Unlock()
+	if srv != nil {
+		log.Println("正在关闭服务器...")
+		if err := srv.Shutdown(ctx); err != nil {
+			if !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled) {
+				log.Printf("关闭服务器失败: %v", err)
+			}
+			log.Println("强制断开剩余的连接")
+			srv.Close()
+		}
+		log.Println("服务器已关闭")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.Storage != nil {
+		t.Storage.Close()
+	}
+}
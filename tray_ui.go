@@ -0,0 +1,107 @@
+package main
+
+import (
+	_ "embed"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/data/binding"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/widget"
+)
+
+// 托盘图标，未打包运行时应用没有图标
+//
+//go:embed Icon.png
+var trayIconPNG []byte
+
+// 托盘菜单中需要由主界面完成的操作
+type trayActions struct {
+	ToggleSharing func() // 开始或停止共享
+	Quit          func() // 停止共享后退出
+}
+
+// 在系统托盘中显示图标和菜单，平台不支持托盘时返回 false
+func setupTray(window fyne.Window, state *AppState, actions trayActions) bool {
+	desk, ok := fyne.CurrentApp().(desktop.App)
+	if !ok {
+		return false
+	}
+
+	status := fyne.NewMenuItem("", nil)
+	status.Disabled = true
+	toggle := fyne.NewMenuItem("", actions.ToggleSharing)
+	quit := fyne.NewMenuItem(tr("ui.tray_quit"), actions.Quit)
+	quit.IsQuit = true
+	menu := fyne.NewMenu(tr("ui.window_title"),
+		status,
+		fyne.NewMenuItem(tr("ui.tray_show"), func() {
+			window.Show()
+			window.RequestFocus()
+		}),
+		fyne.NewMenuItemSeparator(),
+		toggle,
+		fyne.NewMenuItem(tr("ui.tray_address"), func() {
+			window.Show()
+			showAddressDialog(window, state)
+		}),
+		fyne.NewMenuItem(tr("ui.tray_open_folder"), func() {
+			uploadDir, _ := state.UploadDir.Get()
+			if uploadDir == "" {
+				window.Show()
+				showToast(tr("ui.folder_required"), window)
+				return
+			}
+			openFolder(uploadDir)
+		}),
+		fyne.NewMenuItem(tr("ui.notify_reveal"), func() {
+			if !state.Notifier.RevealLatest() {
+				window.Show()
+				showToast(tr("ui.notify_none"), window)
+			}
+		}),
+		fyne.NewMenuItemSeparator(),
+		quit,
+	)
+
+	// 共享状态变化时更新菜单，托盘菜单需要重新设置才会刷新
+	refresh := binding.NewDataListener(func() {
+		running, _ := state.ServerRunning.Get()
+		if running {
+			address, _ := state.ServerAddress.Get()
+			status.Label = tr("ui.tray_sharing", address)
+			toggle.Label = tr("ui.stop_sharing")
+		} else {
+			status.Label = tr("ui.tray_idle")
+			toggle.Label = tr("ui.start_sharing")
+		}
+		desk.SetSystemTrayMenu(menu)
+	})
+	state.ServerRunning.AddListener(refresh)
+	state.ServerAddress.AddListener(refresh)
+	desk.SetSystemTrayIcon(fyne.NewStaticResource("Icon.png", trayIconPNG))
+	return true
+}
+
+// 显示访问地址和二维码，未在共享时提示先开始共享
+func showAddressDialog(window fyne.Window, state *AppState) {
+	if running, _ := state.ServerRunning.Get(); !running {
+		showToast(tr("ui.tray_not_sharing"), window)
+		return
+	}
+	address, _ := state.ServerAddress.Get()
+	davAddress, _ := state.DAVAddress.Get()
+	content := container.NewVBox(
+		container.NewHBox(widget.NewLabel(tr("ui.browser_address")), widget.NewLabel(address)),
+		container.NewHBox(widget.NewLabel(tr("ui.dav_address")), widget.NewLabel(davAddress)),
+	)
+	if img, err := newQRImage(address, 256); err == nil {
+		content.Add(img)
+	}
+	copyBtn := widget.NewButton(tr("ui.link_copy"), func() {
+		fyne.CurrentApp().Clipboard().SetContent(address)
+	})
+	content.Add(container.NewCenter(copyBtn))
+	dialog.ShowCustom(tr("ui.tray_address"), tr("ui.close"), content, window)
+}